@@ -18,6 +18,9 @@ func main() {
 	// Initialize kernel with configuration
 	k := kernel.Init(cnf)
 
+	// Start the background workers, they are drained when the server is shut down
+	k.StartWorkers(context.Background())
+
 	// Start the server in a goroutine
 	serverErrCh := make(chan error)
 	go func() {
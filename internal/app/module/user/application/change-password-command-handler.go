@@ -0,0 +1,53 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+)
+
+type ChangePasswordCommand struct {
+	Email           string `validate:"required,email"`
+	CurrentPassword string `validate:"required"`
+	NewPassword     string `validate:"required,min=8"`
+}
+
+func (c ChangePasswordCommand) Id() string {
+	return "change-password-command"
+}
+
+func (c ChangePasswordCommand) OwnerEmail() string {
+	return c.Email
+}
+
+func (c ChangePasswordCommand) OwnerPermission() user_domain.Permission {
+	return user_domain.PermissionUsersWrite
+}
+
+type ChangePasswordCommandHandler struct {
+	r   user_domain.UserRepository
+	pe  user_domain.PasswordEncrypter
+	rts user_domain.TokenRevocationStore
+}
+
+func NewChangePasswordCommandHandler(
+	r user_domain.UserRepository,
+	pe user_domain.PasswordEncrypter,
+	rts user_domain.TokenRevocationStore,
+) *ChangePasswordCommandHandler {
+	return &ChangePasswordCommandHandler{r: r, pe: pe, rts: rts}
+}
+
+// Handle replaces the password of the user once it proves it knows the current one, signing out every
+// session it had, the one changing the password included.
+func (cpch ChangePasswordCommandHandler) Handle(ctx context.Context, c *ChangePasswordCommand) error {
+	user, err := cpch.r.FindByEmail(ctx, c.Email)
+	if err != nil {
+		return err
+	}
+
+	if err := cpch.pe.VerifyPassword(user.HashedPassword, c.CurrentPassword); err != nil {
+		return user_domain.NewWrongPassword(user.Email)
+	}
+
+	return changePassword(ctx, cpch.r, cpch.pe, cpch.rts, user, c.NewPassword)
+}
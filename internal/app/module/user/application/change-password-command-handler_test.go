@@ -0,0 +1,60 @@
+package user_application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChangePasswordCommandHandler_Handle(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	mockRevocations := new(MockTokenRevocationStore)
+	handler := user_application.NewChangePasswordCommandHandler(mockRepo, mockEncrypter, mockRevocations)
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", HashedPassword: "oldHash"}
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockEncrypter.On("VerifyPassword", "oldHash", "current-password").Return(nil)
+	mockEncrypter.On("GenerateHashedPassword", false, "new-password").Return("newHash", nil)
+	mockRepo.On("Save", ctx, user).Return(nil)
+	mockRevocations.On("RevokeIssuedBefore", ctx, user.Email, mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.ChangePasswordCommand{
+		Email:           user.Email,
+		CurrentPassword: "current-password",
+		NewPassword:     "new-password",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "newHash", user.HashedPassword)
+	mockRevocations.AssertExpectations(t)
+}
+
+func TestChangePasswordCommandHandler_Handle_WrongCurrentPassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	mockRevocations := new(MockTokenRevocationStore)
+	handler := user_application.NewChangePasswordCommandHandler(mockRepo, mockEncrypter, mockRevocations)
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", HashedPassword: "oldHash"}
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockEncrypter.On("VerifyPassword", "oldHash", "wrong-password").Return(errors.New("mismatch"))
+
+	err := handler.Handle(ctx, &user_application.ChangePasswordCommand{
+		Email:           user.Email,
+		CurrentPassword: "wrong-password",
+		NewPassword:     "new-password",
+	})
+
+	assert.IsType(t, &user_domain.WrongPassword{}, err)
+	assert.Equal(t, "oldHash", user.HashedPassword)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockRevocations.AssertNotCalled(t, "RevokeIssuedBefore", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -4,16 +4,15 @@ import (
 	"context"
 	"errors"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"github.com/mik3lon/starter-template/pkg/bus"
 )
 
 type CreateUserCommand struct {
-	ID                string
+	ID                string `validate:"required"`
 	Name              string
 	Surname           string
-	Username          string
-	PlainPassword     string
-	Email             string
+	Username          string `validate:"required"`
+	PlainPassword     string `validate:"required_if=IsFormSocialAuth false"`
+	Email             string `validate:"required,email"`
 	Role              string
 	ProfilePictureUrl string
 	IsFormSocialAuth  bool
@@ -23,6 +22,16 @@ func (c CreateUserCommand) Id() string {
 	return "create-user-command"
 }
 
+func (c CreateUserCommand) IdempotencyOwner() string {
+	return c.Email
+}
+
+// IdempotencyPayload leaves out the ID, which is generated on every request.
+func (c CreateUserCommand) IdempotencyPayload() any {
+	c.ID = ""
+	return c
+}
+
 type CreateUserCommandHandler struct {
 	r  user_domain.UserRepository
 	pe user_domain.PasswordEncrypter
@@ -35,17 +44,17 @@ func NewCreateUserCommandHandler(
 	return &CreateUserCommandHandler{r: r, pe: pe}
 }
 
-func (cuch CreateUserCommandHandler) Handle(ctx context.Context, c bus.Dto) error {
-	cuc, ok := c.(*CreateUserCommand)
-	if !ok {
-		return errors.New("invalid command")
-	}
-
+func (cuch CreateUserCommandHandler) Handle(ctx context.Context, cuc *CreateUserCommand) error {
 	password, err := cuch.pe.GenerateHashedPassword(cuc.IsFormSocialAuth, cuc.PlainPassword)
 	if err != nil {
 		return errors.New("failed to generate hashed password")
 	}
 
+	role, err := user_domain.NewRole(cuc.Role)
+	if err != nil {
+		return err
+	}
+
 	user := user_domain.CreateUser(
 		cuc.ID,
 		cuc.Username,
@@ -53,7 +62,7 @@ func (cuch CreateUserCommandHandler) Handle(ctx context.Context, c bus.Dto) erro
 		password,
 		cuc.Name,
 		cuc.Surname,
-		cuc.Role,
+		role,
 		cuc.ProfilePictureUrl,
 	)
 
@@ -57,22 +57,11 @@ func TestCreateUserCommandHandler_Handle(t *testing.T) {
 			user.Username == command.Username &&
 			user.Email == command.Email &&
 			user.HashedPassword == hashedPassword &&
-			user.Role == command.Role &&
+			string(user.Role) == command.Role &&
 			user.ProfilePictureUrl == command.ProfilePictureUrl
 	}))
 }
 
-func TestCreateUserCommandHandler_Handle_InvalidCommand(t *testing.T) {
-	// Create handler
-	handler := user_application.NewCreateUserCommandHandler(nil, nil)
-
-	// Act
-	err := handler.Handle(context.Background(), nil)
-
-	// Assert
-	assert.EqualError(t, err, "invalid command")
-}
-
 func TestCreateUserCommandHandler_Handle_HashingError(t *testing.T) {
 	// Mock dependencies
 	mockRepo := new(MockUserRepository)
@@ -147,3 +136,45 @@ func TestCreateUserCommandHandler_Handle_SaveError(t *testing.T) {
 	mockEncrypter.AssertCalled(t, "GenerateHashedPassword", command.IsFormSocialAuth, command.PlainPassword)
 	mockRepo.AssertCalled(t, "Save", ctx, mock.AnythingOfType("*user_domain.User"))
 }
+
+func TestCreateUserCommandHandler_Handle_NormalizesLegacyRoles(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	handler := user_application.NewCreateUserCommandHandler(mockRepo, mockEncrypter)
+	ctx := context.Background()
+
+	mockEncrypter.On("GenerateHashedPassword", false, "password123").Return("hashedPassword123", nil)
+	mockRepo.On("Save", ctx, mock.MatchedBy(func(user *user_domain.User) bool {
+		return user.Role == user_domain.RoleUser
+	})).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.CreateUserCommand{
+		ID:            "123",
+		Username:      "johndoe",
+		PlainPassword: "password123",
+		Email:         "johndoe@example.com",
+		Role:          "ROLE_USER",
+	})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateUserCommandHandler_Handle_RejectsUnknownRoles(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	handler := user_application.NewCreateUserCommandHandler(mockRepo, mockEncrypter)
+
+	mockEncrypter.On("GenerateHashedPassword", false, "password123").Return("hashedPassword123", nil)
+
+	err := handler.Handle(context.Background(), &user_application.CreateUserCommand{
+		ID:            "123",
+		Username:      "johndoe",
+		PlainPassword: "password123",
+		Email:         "johndoe@example.com",
+		Role:          "superuser",
+	})
+
+	assert.IsType(t, &user_domain.InvalidRole{}, err)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
@@ -2,17 +2,19 @@ package user_application
 
 import (
 	"context"
-	"errors"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"github.com/mik3lon/starter-template/pkg/bus"
 )
 
 type FindUserQuery struct {
-	Email string
+	Email string `validate:"required,email"`
 }
 
-func (c FindUserQuery) Id() string {
-	return "find-user-query-handler"
+func (q FindUserQuery) OwnerEmail() string {
+	return q.Email
+}
+
+func (q FindUserQuery) OwnerPermission() user_domain.Permission {
+	return user_domain.PermissionUsersRead
 }
 
 type FindUserQueryHandler struct {
@@ -23,12 +25,7 @@ func NewFindUserQueryHandler(r user_domain.UserRepository) *FindUserQueryHandler
 	return &FindUserQueryHandler{r: r}
 }
 
-func (fuqh FindUserQueryHandler) Handle(ctx context.Context, query bus.Dto) (interface{}, error) {
-	q, ok := query.(*FindUserQuery)
-	if !ok {
-		return nil, errors.New("invalid query")
-	}
-
+func (fuqh FindUserQueryHandler) Handle(ctx context.Context, q *FindUserQuery) (*FindUserResponse, error) {
 	user, err := fuqh.r.FindByEmail(ctx, q.Email)
 	if err != nil {
 		return nil, err
@@ -47,30 +47,13 @@ func TestFindUserQueryHandler_Handle(t *testing.T) {
 	assert.NotNil(t, result)
 
 	// Validate the result structure
-	response, ok := result.(*user_application.FindUserResponse)
-	assert.True(t, ok)
-	assert.Equal(t, expectedUser.ID, response.ID)
-	assert.Equal(t, expectedUser.Email, response.Email)
-	assert.Equal(t, expectedUser.Name, response.Name)
-	assert.Equal(t, expectedUser.Surname, response.Surname)
-	assert.Equal(t, expectedUser.Username, response.Username)
-	assert.Equal(t, expectedUser.Role, response.Role)
-	assert.Equal(t, expectedUser.ProfilePictureUrl, response.ProfilePictureUrl)
-}
-
-func TestFindUserQueryHandler_Handle_InvalidQuery(t *testing.T) {
-	// Mock dependencies
-	mockRepo := new(MockUserRepository)
-
-	// Create the handler
-	handler := user_application.NewFindUserQueryHandler(mockRepo)
-
-	// Act
-	result, err := handler.Handle(context.Background(), nil)
-
-	// Assert
-	assert.EqualError(t, err, "invalid query")
-	assert.Nil(t, result)
+	assert.Equal(t, expectedUser.ID, result.ID)
+	assert.Equal(t, expectedUser.Email, result.Email)
+	assert.Equal(t, expectedUser.Name, result.Name)
+	assert.Equal(t, expectedUser.Surname, result.Surname)
+	assert.Equal(t, expectedUser.Username, result.Username)
+	assert.Equal(t, string(expectedUser.Role), result.Role)
+	assert.Equal(t, expectedUser.ProfilePictureUrl, result.ProfilePictureUrl)
 }
 
 func TestFindUserQueryHandler_Handle_UserNotFound(t *testing.T) {
@@ -24,7 +24,7 @@ func NewFindUserResponseFromUser(u *user_domain.User) *FindUserResponse {
 		Email:             u.Email,
 		Name:              u.Name,
 		Surname:           u.Surname,
-		Role:              u.Role,
+		Role:              string(u.Role),
 		ProfilePictureUrl: u.ProfilePictureUrl,
 		CreatedAt:         u.CreatedAt,
 		UpdatedAt:         u.UpdatedAt,
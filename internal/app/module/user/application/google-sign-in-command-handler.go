@@ -5,22 +5,21 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"github.com/mik3lon/starter-template/pkg/bus"
+	"time"
 )
 
 type GoogleSignInQuery struct {
-	IdToken string
-}
-
-func (c GoogleSignInQuery) Id() string {
-	return "google-sign-in-query"
+	IdToken string `validate:"required"`
 }
 
 type GoogleSignInQueryHandler struct {
-	r  user_domain.UserRepository
-	tv user_domain.IdTokenValidator
-	ue user_domain.UserEncoder
-	pe user_domain.PasswordEncrypter
+	r   user_domain.UserRepository
+	tv  user_domain.IdTokenValidator
+	ue  user_domain.UserEncoder
+	pe  user_domain.PasswordEncrypter
+	rts user_domain.TokenRevocationStore
+	// requireVerifiedEmail refuses to sign in users whose email Google did not verify.
+	requireVerifiedEmail bool
 }
 
 func NewGoogleSignInQueryHandler(
@@ -28,20 +27,20 @@ func NewGoogleSignInQueryHandler(
 	tv user_domain.IdTokenValidator,
 	ue user_domain.UserEncoder,
 	pe user_domain.PasswordEncrypter,
+	rts user_domain.TokenRevocationStore,
+	requireVerifiedEmail bool,
 ) *GoogleSignInQueryHandler {
-	return &GoogleSignInQueryHandler{r: r, tv: tv, ue: ue, pe: pe}
+	return &GoogleSignInQueryHandler{r: r, tv: tv, ue: ue, pe: pe, rts: rts, requireVerifiedEmail: requireVerifiedEmail}
 }
 
-func (cuch GoogleSignInQueryHandler) Handle(ctx context.Context, c bus.Dto) (interface{}, error) {
-	cuc, ok := c.(*GoogleSignInQuery)
-	if !ok {
-		return nil, errors.New("invalid query")
-	}
-
+func (cuch GoogleSignInQueryHandler) Handle(ctx context.Context, cuc *GoogleSignInQuery) (*user_domain.TokenDetails, error) {
 	idTokenClaims, err := cuch.tv.Validate(ctx, cuc.IdToken)
 	if err != nil {
 		return nil, err
 	}
+	if cuch.requireVerifiedEmail && !idTokenClaims.EmailVerified {
+		return nil, user_domain.NewEmailNotVerified(idTokenClaims.Email)
+	}
 
 	user, err := cuch.r.FindByEmail(ctx, idTokenClaims.Email)
 	switch {
@@ -60,9 +59,12 @@ func (cuch GoogleSignInQueryHandler) Handle(ctx context.Context, c bus.Dto) (int
 			password,
 			idTokenClaims.Name,
 			idTokenClaims.Surname,
-			"ROL_USER",
+			user_domain.RoleUser,
 			idTokenClaims.ProfilePictureUrl,
 		)
+		if idTokenClaims.EmailVerified {
+			user.MarkEmailVerified()
+		}
 
 		saveErr := cuch.r.Save(ctx, user)
 		if saveErr != nil {
@@ -72,5 +74,22 @@ func (cuch GoogleSignInQueryHandler) Handle(ctx context.Context, c bus.Dto) (int
 		return nil, err
 	}
 
+	// Users signed up with a password may sign in with Google later on, proving they own the email. Until
+	// then the account may have been pre-registered by someone else, so its password and sessions are dropped.
+	if idTokenClaims.EmailVerified && !user.EmailVerified {
+		password, err := cuch.pe.GenerateHashedPassword(true, "")
+		if err != nil {
+			return nil, errors.New("failed to generate hashed password")
+		}
+		user.ChangePassword(password)
+		user.MarkEmailVerified()
+		if err := cuch.r.Save(ctx, user); err != nil {
+			return nil, err
+		}
+		if err := cuch.rts.RevokeIssuedBefore(ctx, user.Email, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
 	return cuch.ue.GenerateToken(user)
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -21,7 +22,7 @@ func TestGoogleSignInQueryHandler_UserFoundSuccessfully(t *testing.T) {
 	mockEncoder := new(MockUserEncoder)
 	passwordEncrypter := new(MockPasswordEncrypter)
 
-	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter)
+	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter, new(MockTokenRevocationStore), false)
 
 	idToken := "test-id-token"
 	email := "test@example.com"
@@ -65,7 +66,7 @@ func TestGoogleSignInQueryHandler_UserNotFound_CreatesNewUser(t *testing.T) {
 	mockEncoder := new(MockUserEncoder)
 	passwordEncrypter := new(MockPasswordEncrypter)
 
-	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter)
+	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter, new(MockTokenRevocationStore), false)
 
 	idToken := "test-id-token"
 	email := "test@example.com"
@@ -115,7 +116,7 @@ func TestGoogleSignInQueryHandler_UserNotFound_CreatesNewUser(t *testing.T) {
 	mockEncoder.AssertCalled(t, "GenerateToken", mock.Anything)
 }
 
-func TestGoogleSignInQueryHandler_InvalidQuery(t *testing.T) {
+func TestGoogleSignInQueryHandler_TokenValidationFailed(t *testing.T) {
 	ctx := context.Background()
 
 	// Arrange
@@ -124,37 +125,65 @@ func TestGoogleSignInQueryHandler_InvalidQuery(t *testing.T) {
 	mockEncoder := new(MockUserEncoder)
 	passwordEncrypter := new(MockPasswordEncrypter)
 
-	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter)
+	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter, new(MockTokenRevocationStore), false)
+
+	idToken := "test-id-token"
+	mockValidator.On("Validate", ctx, idToken).Return(nil, errors.New("invalid token"))
 
 	// Act
-	result, err := handler.Handle(ctx, nil)
+	result, err := handler.Handle(ctx, &user_application.GoogleSignInQuery{IdToken: idToken})
 
 	// Assert
 	require.Nil(t, result)
 	require.Error(t, err)
-	require.Equal(t, "invalid query", err.Error())
+	require.Equal(t, "invalid token", err.Error())
+	mockValidator.AssertCalled(t, "Validate", ctx, idToken)
 }
 
-func TestGoogleSignInQueryHandler_TokenValidationFailed(t *testing.T) {
+func TestGoogleSignInQueryHandler_VerifiedEmail_DropsPasswordAndSessionsOfUnverifiedAccounts(t *testing.T) {
 	ctx := context.Background()
 
-	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockValidator := new(MockIdTokenValidator)
 	mockEncoder := new(MockUserEncoder)
 	passwordEncrypter := new(MockPasswordEncrypter)
+	mockRevocations := new(MockTokenRevocationStore)
 
-	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter)
+	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, mockEncoder, passwordEncrypter, mockRevocations, false)
 
-	idToken := "test-id-token"
-	mockValidator.On("Validate", ctx, idToken).Return(nil, errors.New("invalid token"))
+	email := "test@example.com"
+	user := &user_domain.User{ID: uuid.NewString(), Email: email, HashedPassword: "preregisteredPassword"}
+	expectedToken := &user_domain.TokenDetails{UserEmail: email}
 
-	// Act
-	result, err := handler.Handle(ctx, &user_application.GoogleSignInQuery{IdToken: idToken})
+	mockValidator.On("Validate", ctx, "test-id-token").Return(&user_domain.IdTokenClaims{Email: email, EmailVerified: true}, nil)
+	mockRepo.On("FindByEmail", ctx, email).Return(user, nil)
+	passwordEncrypter.On("GenerateHashedPassword", true, "").Return("randomPassword", nil)
+	mockRepo.On("Save", ctx, user).Return(nil)
+	mockRevocations.On("RevokeIssuedBefore", ctx, email, mock.AnythingOfType("time.Time")).Return(nil)
+	mockEncoder.On("GenerateToken", user).Return(expectedToken, nil)
 
-	// Assert
-	require.Nil(t, result)
-	require.Error(t, err)
-	require.Equal(t, "invalid token", err.Error())
-	mockValidator.AssertCalled(t, "Validate", ctx, idToken)
+	result, err := handler.Handle(ctx, &user_application.GoogleSignInQuery{IdToken: "test-id-token"})
+
+	require.NoError(t, err)
+	assert.Equal(t, expectedToken, result)
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, "randomPassword", user.HashedPassword)
+	mockRevocations.AssertExpectations(t)
+}
+
+func TestGoogleSignInQueryHandler_RequireVerifiedEmail_RejectsUnverifiedEmails(t *testing.T) {
+	ctx := context.Background()
+
+	mockRepo := new(MockUserRepository)
+	mockValidator := new(MockIdTokenValidator)
+
+	handler := user_application.NewGoogleSignInQueryHandler(mockRepo, mockValidator, new(MockUserEncoder), new(MockPasswordEncrypter), new(MockTokenRevocationStore), true)
+
+	mockValidator.On("Validate", ctx, "test-id-token").Return(&user_domain.IdTokenClaims{Email: "test@example.com"}, nil)
+
+	result, err := handler.Handle(ctx, &user_application.GoogleSignInQuery{IdToken: "test-id-token"})
+
+	assert.Nil(t, result)
+	assert.IsType(t, &user_domain.EmailNotVerified{}, err)
+	mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
 }
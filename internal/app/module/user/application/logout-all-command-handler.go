@@ -0,0 +1,28 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"time"
+)
+
+type LogoutAllCommand struct {
+	Email string `validate:"required,email"`
+}
+
+func (c LogoutAllCommand) Id() string {
+	return "logout-all-command"
+}
+
+type LogoutAllCommandHandler struct {
+	rts user_domain.TokenRevocationStore
+}
+
+func NewLogoutAllCommandHandler(rts user_domain.TokenRevocationStore) *LogoutAllCommandHandler {
+	return &LogoutAllCommandHandler{rts: rts}
+}
+
+// Handle revokes every access and refresh token issued to the user so far, signing out all its devices.
+func (lach LogoutAllCommandHandler) Handle(ctx context.Context, c *LogoutAllCommand) error {
+	return lach.rts.RevokeIssuedBefore(ctx, c.Email, time.Now())
+}
@@ -0,0 +1,57 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"time"
+)
+
+type LogoutCommand struct {
+	Email     string `validate:"required,email"`
+	TokenId   string `validate:"required"`
+	ExpiresAt time.Time
+	// RefreshToken, when given, is revoked along with the refresh tokens rotated from it.
+	RefreshToken string
+}
+
+func (c LogoutCommand) Id() string {
+	return "logout-command"
+}
+
+type LogoutCommandHandler struct {
+	rts user_domain.TokenRevocationStore
+	rtr user_domain.RefreshTokenRepository
+	ue  user_domain.UserEncoder
+}
+
+func NewLogoutCommandHandler(
+	rts user_domain.TokenRevocationStore,
+	rtr user_domain.RefreshTokenRepository,
+	ue user_domain.UserEncoder,
+) *LogoutCommandHandler {
+	return &LogoutCommandHandler{rts: rts, rtr: rtr, ue: ue}
+}
+
+// Handle revokes the access token of the session being closed and, if given, its refresh token family.
+func (lch LogoutCommandHandler) Handle(ctx context.Context, c *LogoutCommand) error {
+	if c.RefreshToken != "" {
+		claims, err := lch.ue.DecryptToken(c.RefreshToken)
+		if err != nil {
+			return user_domain.NewInvalidRefreshToken()
+		}
+
+		token, err := user_domain.NewRefreshTokenFromClaims(claims)
+		if err != nil {
+			return err
+		}
+		if token.UserEmail != c.Email {
+			return user_domain.NewInvalidRefreshToken()
+		}
+
+		if err := lch.rtr.RevokeFamily(ctx, token); err != nil {
+			return err
+		}
+	}
+
+	return lch.rts.Revoke(ctx, c.TokenId, c.ExpiresAt)
+}
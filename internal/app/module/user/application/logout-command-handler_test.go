@@ -0,0 +1,71 @@
+package user_application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLogoutCommandHandler_Handle_RevokesAccessTokenAndRefreshFamily(t *testing.T) {
+	mockRevocations := new(MockTokenRevocationStore)
+	mockTokens := new(MockRefreshTokenRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewLogoutCommandHandler(mockRevocations, mockTokens, mockEncoder)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	mockEncoder.On("DecryptToken", "refresh-token").Return(refreshClaims(user_domain.RefreshTokenType), nil)
+	mockTokens.On("RevokeFamily", ctx, mock.MatchedBy(func(token *user_domain.RefreshToken) bool { return token.FamilyId == "family-1" })).Return(nil)
+	mockRevocations.On("Revoke", ctx, "access-1", expiresAt).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.LogoutCommand{
+		Email:        "johndoe@example.com",
+		TokenId:      "access-1",
+		ExpiresAt:    expiresAt,
+		RefreshToken: "refresh-token",
+	})
+
+	assert.NoError(t, err)
+	mockTokens.AssertExpectations(t)
+	mockRevocations.AssertExpectations(t)
+}
+
+func TestLogoutCommandHandler_Handle_RejectsRefreshTokensOfOtherUsers(t *testing.T) {
+	mockRevocations := new(MockTokenRevocationStore)
+	mockTokens := new(MockRefreshTokenRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewLogoutCommandHandler(mockRevocations, mockTokens, mockEncoder)
+
+	mockEncoder.On("DecryptToken", "refresh-token").Return(refreshClaims(user_domain.RefreshTokenType), nil)
+
+	err := handler.Handle(context.Background(), &user_application.LogoutCommand{
+		Email:        "janedoe@example.com",
+		TokenId:      "access-1",
+		RefreshToken: "refresh-token",
+	})
+
+	assert.IsType(t, &user_domain.InvalidRefreshToken{}, err)
+	mockTokens.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+	mockRevocations.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogoutAllCommandHandler_Handle_RevokesTokensIssuedSoFar(t *testing.T) {
+	mockRevocations := new(MockTokenRevocationStore)
+	handler := user_application.NewLogoutAllCommandHandler(mockRevocations)
+	ctx := context.Background()
+	before := time.Now()
+
+	mockRevocations.On("RevokeIssuedBefore", ctx, "johndoe@example.com", mock.MatchedBy(func(at time.Time) bool {
+		return !at.Before(before)
+	})).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.LogoutAllCommand{Email: "johndoe@example.com"})
+
+	assert.NoError(t, err)
+	mockRevocations.AssertExpectations(t)
+}
@@ -0,0 +1,26 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"time"
+)
+
+type PurgeUsedRefreshTokensCommand struct{}
+
+func (c PurgeUsedRefreshTokensCommand) Id() string {
+	return "purge-used-refresh-tokens-command"
+}
+
+type PurgeUsedRefreshTokensCommandHandler struct {
+	rtr user_domain.RefreshTokenRepository
+}
+
+func NewPurgeUsedRefreshTokensCommandHandler(rtr user_domain.RefreshTokenRepository) *PurgeUsedRefreshTokensCommandHandler {
+	return &PurgeUsedRefreshTokensCommandHandler{rtr: rtr}
+}
+
+// Handle forgets the used refresh tokens that already expired, reuse of those is rejected by their expiration.
+func (purtch PurgeUsedRefreshTokensCommandHandler) Handle(ctx context.Context, c *PurgeUsedRefreshTokensCommand) error {
+	return purtch.rtr.PurgeExpired(ctx, time.Now())
+}
@@ -0,0 +1,23 @@
+package user_application_test
+
+import (
+	"context"
+	"testing"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurgeUsedRefreshTokensCommandHandler_Handle(t *testing.T) {
+	mockTokens := new(MockRefreshTokenRepository)
+	handler := user_application.NewPurgeUsedRefreshTokensCommandHandler(mockTokens)
+	ctx := context.Background()
+
+	mockTokens.On("PurgeExpired", ctx, mock.Anything).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.PurgeUsedRefreshTokensCommand{})
+
+	assert.NoError(t, err)
+	mockTokens.AssertExpectations(t)
+}
@@ -0,0 +1,91 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"time"
+)
+
+// RefreshTokenCommand rotates RefreshToken, a command so the token is burnt within the unit of work.
+type RefreshTokenCommand struct {
+	RefreshToken string `validate:"required"`
+	// Tokens is set to the rotated tokens once the command succeeds.
+	Tokens *user_domain.TokenDetails `json:"-"`
+}
+
+func (c RefreshTokenCommand) Id() string {
+	return "refresh-token-command"
+}
+
+type RefreshTokenCommandHandler struct {
+	r   user_domain.UserRepository
+	rtr user_domain.RefreshTokenRepository
+	rts user_domain.TokenRevocationStore
+	ue  user_domain.UserEncoder
+}
+
+func NewRefreshTokenCommandHandler(
+	r user_domain.UserRepository,
+	rtr user_domain.RefreshTokenRepository,
+	rts user_domain.TokenRevocationStore,
+	ue user_domain.UserEncoder,
+) *RefreshTokenCommandHandler {
+	return &RefreshTokenCommandHandler{r: r, rtr: rtr, rts: rts, ue: ue}
+}
+
+// Handle rotates the refresh token of c, revoking its family when it was already used.
+func (rtch RefreshTokenCommandHandler) Handle(ctx context.Context, c *RefreshTokenCommand) error {
+	claims, err := rtch.ue.DecryptToken(c.RefreshToken)
+	if err != nil {
+		return user_domain.NewInvalidRefreshToken()
+	}
+
+	token, err := user_domain.NewRefreshTokenFromClaims(claims)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := rtch.rtr.IsFamilyRevoked(ctx, token.FamilyId)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return user_domain.NewInvalidRefreshToken()
+	}
+
+	// Signing out of all devices revokes refresh tokens too, not only the access ones.
+	issuedToken, err := user_domain.NewIssuedTokenFromClaims(claims)
+	if err != nil {
+		return user_domain.NewInvalidRefreshToken()
+	}
+	revoked, err = rtch.rts.IsRevoked(ctx, issuedToken)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return user_domain.NewInvalidRefreshToken()
+	}
+
+	user, err := rtch.r.FindByEmail(ctx, token.UserEmail)
+	if err != nil {
+		return err
+	}
+
+	// The token is only burnt once nothing else can fail.
+	first, err := rtch.rtr.Use(ctx, token, time.Now())
+	if err != nil {
+		return err
+	}
+	if !first {
+		// Revoked outside of the unit of work, which is rolled back as the command fails.
+		if err := rtch.rtr.RevokeFamily(transaction.Detach(ctx), token); err != nil {
+			return err
+		}
+		return user_domain.NewRefreshTokenReused(token.FamilyId)
+	}
+
+	c.Tokens, err = rtch.ue.RotateToken(user, token.FamilyId)
+
+	return err
+}
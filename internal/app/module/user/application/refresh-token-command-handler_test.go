@@ -0,0 +1,124 @@
+package user_application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func refreshClaims(tokenType string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":                        "johndoe@example.com",
+		"exp":                        float64(time.Now().Add(time.Hour).Unix()),
+		"jti":                        "token-1",
+		user_domain.TokenTypeClaim:   tokenType,
+		user_domain.TokenFamilyClaim: "family-1",
+	}
+}
+
+func TestRefreshTokenCommandHandler_Handle_RotatesToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockRefreshTokenRepository)
+	mockRevocations := new(MockTokenRevocationStore)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewRefreshTokenCommandHandler(mockRepo, mockTokens, mockRevocations, mockEncoder)
+	ctx := context.Background()
+
+	user := &user_domain.User{Email: "johndoe@example.com"}
+	tokenDetails := &user_domain.TokenDetails{UserEmail: user.Email, RefreshToken: "rotated"}
+
+	mockEncoder.On("DecryptToken", "refresh-token").Return(refreshClaims(user_domain.RefreshTokenType), nil)
+	mockTokens.On("IsFamilyRevoked", ctx, "family-1").Return(false, nil)
+	mockRevocations.On("IsRevoked", ctx, mock.Anything).Return(false, nil)
+	mockTokens.On("Use", ctx, mock.MatchedBy(func(token *user_domain.RefreshToken) bool { return token.ID == "token-1" }), mock.Anything).Return(true, nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockEncoder.On("RotateToken", user, "family-1").Return(tokenDetails, nil)
+
+	c := &user_application.RefreshTokenCommand{RefreshToken: "refresh-token"}
+	err := handler.Handle(ctx, c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, tokenDetails, c.Tokens)
+}
+
+func TestRefreshTokenCommandHandler_Handle_RevokesFamilyOnReuse(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockRefreshTokenRepository)
+	mockRevocations := new(MockTokenRevocationStore)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewRefreshTokenCommandHandler(mockRepo, mockTokens, mockRevocations, mockEncoder)
+	ctx := context.Background()
+
+	mockEncoder.On("DecryptToken", "refresh-token").Return(refreshClaims(user_domain.RefreshTokenType), nil)
+	mockTokens.On("IsFamilyRevoked", ctx, "family-1").Return(false, nil)
+	mockRevocations.On("IsRevoked", ctx, mock.Anything).Return(false, nil)
+	mockRepo.On("FindByEmail", ctx, "johndoe@example.com").Return(&user_domain.User{Email: "johndoe@example.com"}, nil)
+	mockTokens.On("Use", ctx, mock.Anything, mock.Anything).Return(false, nil)
+	mockTokens.On("RevokeFamily", ctx, mock.Anything).Return(nil)
+
+	c := &user_application.RefreshTokenCommand{RefreshToken: "refresh-token"}
+	err := handler.Handle(ctx, c)
+
+	assert.Nil(t, c.Tokens)
+	assert.IsType(t, &user_domain.RefreshTokenReused{}, err)
+	mockTokens.AssertCalled(t, "RevokeFamily", ctx, mock.Anything)
+	mockEncoder.AssertNotCalled(t, "RotateToken", mock.Anything, mock.Anything)
+}
+
+func TestRefreshTokenCommandHandler_Handle_DoesNotUseTokenWhenUserLookupFails(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockRefreshTokenRepository)
+	mockRevocations := new(MockTokenRevocationStore)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewRefreshTokenCommandHandler(mockRepo, mockTokens, mockRevocations, mockEncoder)
+	ctx := context.Background()
+
+	mockEncoder.On("DecryptToken", "refresh-token").Return(refreshClaims(user_domain.RefreshTokenType), nil)
+	mockTokens.On("IsFamilyRevoked", ctx, "family-1").Return(false, nil)
+	mockRevocations.On("IsRevoked", ctx, mock.Anything).Return(false, nil)
+	mockRepo.On("FindByEmail", ctx, "johndoe@example.com").Return(nil, errors.New("connection refused"))
+
+	err := handler.Handle(ctx, &user_application.RefreshTokenCommand{RefreshToken: "refresh-token"})
+
+	assert.Error(t, err)
+	mockTokens.AssertNotCalled(t, "Use", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefreshTokenCommandHandler_Handle_RejectsAccessTokens(t *testing.T) {
+	mockTokens := new(MockRefreshTokenRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewRefreshTokenCommandHandler(new(MockUserRepository), mockTokens, new(MockTokenRevocationStore), mockEncoder)
+
+	mockEncoder.On("DecryptToken", "access-token").Return(refreshClaims(user_domain.AccessTokenType), nil)
+
+	err := handler.Handle(context.Background(), &user_application.RefreshTokenCommand{RefreshToken: "access-token"})
+
+	assert.IsType(t, &user_domain.InvalidRefreshToken{}, err)
+	mockTokens.AssertNotCalled(t, "Use", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefreshTokenCommandHandler_Handle_RejectsTokensRevokedByLogoutAll(t *testing.T) {
+	mockTokens := new(MockRefreshTokenRepository)
+	mockRevocations := new(MockTokenRevocationStore)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewRefreshTokenCommandHandler(new(MockUserRepository), mockTokens, mockRevocations, mockEncoder)
+	ctx := context.Background()
+
+	mockEncoder.On("DecryptToken", "refresh-token").Return(refreshClaims(user_domain.RefreshTokenType), nil)
+	mockTokens.On("IsFamilyRevoked", ctx, "family-1").Return(false, nil)
+	mockRevocations.On("IsRevoked", ctx, mock.MatchedBy(func(token *user_domain.IssuedToken) bool {
+		return token.ID == "token-1" && token.UserEmail == "johndoe@example.com"
+	})).Return(true, nil)
+
+	err := handler.Handle(ctx, &user_application.RefreshTokenCommand{RefreshToken: "refresh-token"})
+
+	assert.IsType(t, &user_domain.InvalidRefreshToken{}, err)
+	mockTokens.AssertNotCalled(t, "Use", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,79 @@
+package user_application
+
+import (
+	"context"
+	"errors"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"time"
+)
+
+const PasswordResetEmailTemplate = "password-reset"
+
+type RequestPasswordResetCommand struct {
+	Email string `validate:"required,email"`
+}
+
+func (c RequestPasswordResetCommand) Id() string {
+	return "request-password-reset-command"
+}
+
+// RequestPasswordResetCommandHandler mails the reset token as a link to linkBaseUrl, the front end page
+// choosing the new password with the token of its `token` query parameter. Only the hash of the token is
+// stored, the email is dispatched synchronously on cb so the token never reaches a durable transport.
+// RequestPasswordResetCommand is the one queued, it only carries the email, so requests are answered
+// before the account is looked up, as fast for unknown emails as for known ones.
+type RequestPasswordResetCommandHandler struct {
+	r           user_domain.UserRepository
+	prtr        user_domain.PasswordResetTokenRepository
+	cb          command.Bus
+	ttl         time.Duration
+	linkBaseUrl string
+}
+
+func NewRequestPasswordResetCommandHandler(
+	r user_domain.UserRepository,
+	prtr user_domain.PasswordResetTokenRepository,
+	cb command.Bus,
+	ttl time.Duration,
+	linkBaseUrl string,
+) *RequestPasswordResetCommandHandler {
+	return &RequestPasswordResetCommandHandler{r: r, prtr: prtr, cb: cb, ttl: ttl, linkBaseUrl: linkBaseUrl}
+}
+
+// Handle issues and mails a new password reset token for the email of c, replacing the previous one. Unknown
+// emails are ignored, so the outcome does not tell which emails have an account.
+func (rprch RequestPasswordResetCommandHandler) Handle(ctx context.Context, c *RequestPasswordResetCommand) error {
+	user, err := rprch.r.FindByEmail(ctx, c.Email)
+	if errors.As(err, new(*user_domain.UserNotFound)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(rprch.ttl)
+	token, resetToken, err := user_domain.NewPasswordResetToken(user.Email, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	if err := rprch.prtr.Save(ctx, resetToken); err != nil {
+		return err
+	}
+
+	user.RequestPasswordReset(expiresAt)
+	if err := rprch.r.Save(ctx, user); err != nil {
+		return err
+	}
+
+	return rprch.cb.Dispatch(ctx, &mail.SendEmailCommand{
+		To:       user.Email,
+		Template: PasswordResetEmailTemplate,
+		Data: map[string]string{
+			"link":       tokenLink(rprch.linkBaseUrl, token),
+			"expires_at": formatExpiration(expiresAt),
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package user_application_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestPasswordResetCommandHandler_Handle(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockPasswordResetTokenRepository)
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewRequestPasswordResetCommandHandler(mockRepo, mockTokens, mockBus, time.Hour, "https://app.example.com/reset-password")
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com"}
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockTokens.On("Save", ctx, mock.AnythingOfType("*user_domain.PasswordResetToken")).Return(nil)
+	mockRepo.On("Save", ctx, user).Return(nil)
+	mockBus.On("Dispatch", ctx, mock.AnythingOfType("*mail.SendEmailCommand")).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.RequestPasswordResetCommand{Email: user.Email})
+
+	assert.NoError(t, err)
+	events := user.PullDomainEvents()
+	if assert.Len(t, events, 1) {
+		requested := events[0].(*user_domain.PasswordResetRequested)
+		stored := mockTokens.Calls[0].Arguments.Get(1).(*user_domain.PasswordResetToken)
+		email := mockBus.Calls[0].Arguments.Get(1).(*mail.SendEmailCommand)
+		link, err := url.Parse(email.Data["link"])
+		require.NoError(t, err)
+		token := link.Query().Get("token")
+
+		// Only the hash of the token mailed to the user is stored.
+		assert.Equal(t, user.Email, email.To)
+		assert.Equal(t, user_application.PasswordResetEmailTemplate, email.Template)
+		assert.Equal(t, "https://app.example.com/reset-password", link.Scheme+"://"+link.Host+link.Path)
+		assert.NotEmpty(t, token)
+		assert.NotEqual(t, token, stored.Hash)
+		assert.Equal(t, user_domain.HashPasswordResetToken(token), stored.Hash)
+		assert.Equal(t, user.Email, stored.UserEmail)
+		assert.Equal(t, requested.ExpiresAt, stored.ExpiresAt)
+	}
+}
+
+func TestRequestPasswordResetCommandHandler_Handle_UnknownEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockPasswordResetTokenRepository)
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewRequestPasswordResetCommandHandler(mockRepo, mockTokens, mockBus, time.Hour, "https://app.example.com/reset-password")
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "unknown@example.com").
+		Return(nil, user_domain.NewUserNotFound("unknown@example.com"))
+
+	err := handler.Handle(ctx, &user_application.RequestPasswordResetCommand{Email: "unknown@example.com"})
+
+	assert.NoError(t, err)
+	mockTokens.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockBus.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,76 @@
+package user_application
+
+import (
+	"context"
+	"errors"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"time"
+)
+
+type ResetPasswordCommand struct {
+	Token       string `validate:"required"`
+	NewPassword string `validate:"required,min=8"`
+}
+
+func (c ResetPasswordCommand) Id() string {
+	return "reset-password-command"
+}
+
+type ResetPasswordCommandHandler struct {
+	r    user_domain.UserRepository
+	prtr user_domain.PasswordResetTokenRepository
+	pe   user_domain.PasswordEncrypter
+	rts  user_domain.TokenRevocationStore
+}
+
+func NewResetPasswordCommandHandler(
+	r user_domain.UserRepository,
+	prtr user_domain.PasswordResetTokenRepository,
+	pe user_domain.PasswordEncrypter,
+	rts user_domain.TokenRevocationStore,
+) *ResetPasswordCommandHandler {
+	return &ResetPasswordCommandHandler{r: r, prtr: prtr, pe: pe, rts: rts}
+}
+
+// Handle sets the new password of the owner of the token, signing out every session it had.
+func (rpch ResetPasswordCommandHandler) Handle(ctx context.Context, c *ResetPasswordCommand) error {
+	resetToken, err := rpch.prtr.Consume(ctx, user_domain.HashPasswordResetToken(c.Token))
+	if err != nil {
+		return err
+	}
+	if resetToken == nil || resetToken.IsExpired(time.Now()) {
+		return user_domain.NewInvalidPasswordResetToken()
+	}
+
+	user, err := rpch.r.FindByEmail(ctx, resetToken.UserEmail)
+	if errors.As(err, new(*user_domain.UserNotFound)) {
+		return user_domain.NewInvalidPasswordResetToken()
+	}
+	if err != nil {
+		return err
+	}
+
+	return changePassword(ctx, rpch.r, rpch.pe, rpch.rts, user, c.NewPassword)
+}
+
+// changePassword saves the new password of user and revokes the tokens issued to it until now.
+func changePassword(
+	ctx context.Context,
+	r user_domain.UserRepository,
+	pe user_domain.PasswordEncrypter,
+	rts user_domain.TokenRevocationStore,
+	user *user_domain.User,
+	plainPassword string,
+) error {
+	hashedPassword, err := pe.GenerateHashedPassword(false, plainPassword)
+	if err != nil {
+		return err
+	}
+
+	user.ChangePassword(hashedPassword)
+	if err := r.Save(ctx, user); err != nil {
+		return err
+	}
+
+	return rts.RevokeIssuedBefore(ctx, user.Email, time.Now())
+}
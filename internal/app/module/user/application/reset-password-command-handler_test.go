@@ -0,0 +1,78 @@
+package user_application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResetPasswordCommandHandler_Handle(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockPasswordResetTokenRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	mockRevocations := new(MockTokenRevocationStore)
+	handler := user_application.NewResetPasswordCommandHandler(mockRepo, mockTokens, mockEncrypter, mockRevocations)
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", HashedPassword: "oldHash"}
+	mockTokens.On("Consume", ctx, user_domain.HashPasswordResetToken("reset-token")).Return(&user_domain.PasswordResetToken{
+		UserEmail: user.Email,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockEncrypter.On("GenerateHashedPassword", false, "new-password").Return("newHash", nil)
+	mockRepo.On("Save", ctx, user).Return(nil)
+	mockRevocations.On("RevokeIssuedBefore", ctx, user.Email, mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.ResetPasswordCommand{Token: "reset-token", NewPassword: "new-password"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "newHash", user.HashedPassword)
+	mockRevocations.AssertExpectations(t)
+	events := user.PullDomainEvents()
+	if assert.Len(t, events, 1) {
+		assert.IsType(t, &user_domain.UserPasswordChanged{}, events[0])
+	}
+}
+
+func TestResetPasswordCommandHandler_Handle_UnknownOrUsedToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockPasswordResetTokenRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	mockRevocations := new(MockTokenRevocationStore)
+	handler := user_application.NewResetPasswordCommandHandler(mockRepo, mockTokens, mockEncrypter, mockRevocations)
+	ctx := context.Background()
+
+	mockTokens.On("Consume", ctx, user_domain.HashPasswordResetToken("reset-token")).Return(nil, nil)
+
+	err := handler.Handle(ctx, &user_application.ResetPasswordCommand{Token: "reset-token", NewPassword: "new-password"})
+
+	assert.IsType(t, &user_domain.InvalidPasswordResetToken{}, err)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockRevocations.AssertNotCalled(t, "RevokeIssuedBefore", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPasswordCommandHandler_Handle_ExpiredToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockPasswordResetTokenRepository)
+	mockEncrypter := new(MockPasswordEncrypter)
+	mockRevocations := new(MockTokenRevocationStore)
+	handler := user_application.NewResetPasswordCommandHandler(mockRepo, mockTokens, mockEncrypter, mockRevocations)
+	ctx := context.Background()
+
+	mockTokens.On("Consume", ctx, user_domain.HashPasswordResetToken("reset-token")).Return(&user_domain.PasswordResetToken{
+		UserEmail: "johndoe@example.com",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}, nil)
+
+	err := handler.Handle(ctx, &user_application.ResetPasswordCommand{Token: "reset-token", NewPassword: "new-password"})
+
+	assert.IsType(t, &user_domain.InvalidPasswordResetToken{}, err)
+	mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
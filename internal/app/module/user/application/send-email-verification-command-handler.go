@@ -0,0 +1,88 @@
+package user_application
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"net/url"
+	"time"
+)
+
+const EmailVerificationEmailTemplate = "email-verification"
+
+type SendEmailVerificationCommand struct {
+	Email string `validate:"required,email"`
+}
+
+func (c SendEmailVerificationCommand) Id() string {
+	return "send-email-verification-command"
+}
+
+// SendEmailVerificationCommandHandler mails the verification token as a link to linkBaseUrl, the front end
+// page verifying the email with the token of its `token` query parameter. The email is dispatched
+// synchronously on cb so the token never reaches a durable transport, SendEmailVerificationCommand is the
+// one queued, it only carries the email.
+type SendEmailVerificationCommandHandler struct {
+	r           user_domain.UserRepository
+	ue          user_domain.UserEncoder
+	cb          command.Bus
+	ttl         time.Duration
+	linkBaseUrl string
+}
+
+func NewSendEmailVerificationCommandHandler(
+	r user_domain.UserRepository,
+	ue user_domain.UserEncoder,
+	cb command.Bus,
+	ttl time.Duration,
+	linkBaseUrl string,
+) *SendEmailVerificationCommandHandler {
+	return &SendEmailVerificationCommandHandler{r: r, ue: ue, cb: cb, ttl: ttl, linkBaseUrl: linkBaseUrl}
+}
+
+// Handle issues and mails a new verification token for the email of c, replacing the previous one. Unknown
+// and already verified emails are ignored, so the outcome does not tell which emails have an account.
+func (sevch SendEmailVerificationCommandHandler) Handle(ctx context.Context, c *SendEmailVerificationCommand) error {
+	user, err := sevch.r.FindByEmail(ctx, c.Email)
+	if errors.As(err, new(*user_domain.UserNotFound)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	tokenId := uuid.NewString()
+	expiresAt := time.Now().Add(sevch.ttl)
+	token, err := sevch.ue.GenerateEmailVerificationToken(user, tokenId, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	user.RequestEmailVerification(tokenId, expiresAt)
+	if err := sevch.r.Save(ctx, user); err != nil {
+		return err
+	}
+
+	return sevch.cb.Dispatch(ctx, &mail.SendEmailCommand{
+		To:       user.Email,
+		Template: EmailVerificationEmailTemplate,
+		Data: map[string]string{
+			"link":       tokenLink(sevch.linkBaseUrl, token),
+			"expires_at": formatExpiration(expiresAt),
+		},
+	})
+}
+
+func tokenLink(baseUrl string, token string) string {
+	return baseUrl + "?" + url.Values{"token": {token}}.Encode()
+}
+
+func formatExpiration(expiresAt time.Time) string {
+	return expiresAt.UTC().Format("2006-01-02 15:04 MST")
+}
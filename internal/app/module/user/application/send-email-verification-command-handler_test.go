@@ -0,0 +1,78 @@
+package user_application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSendEmailVerificationCommandHandler_Handle(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewSendEmailVerificationCommandHandler(mockRepo, mockEncoder, mockBus, 24*time.Hour, "https://app.example.com/verify-email")
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com"}
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockEncoder.On("GenerateEmailVerificationToken", user, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+		Return("a.b c", nil)
+	mockRepo.On("Save", ctx, user).Return(nil)
+	mockBus.On("Dispatch", ctx, mock.MatchedBy(func(c *mail.SendEmailCommand) bool {
+		return c.To == user.Email &&
+			c.Template == user_application.EmailVerificationEmailTemplate &&
+			c.Data["link"] == "https://app.example.com/verify-email?token=a.b+c" &&
+			c.Data["expires_at"] != ""
+	})).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.SendEmailVerificationCommand{Email: user.Email})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, user.VerificationTokenId)
+	events := user.PullDomainEvents()
+	if assert.Len(t, events, 1) {
+		requested := events[0].(*user_domain.EmailVerificationRequested)
+		assert.Equal(t, user.Email, requested.Email)
+	}
+	mockBus.AssertExpectations(t)
+}
+
+func TestSendEmailVerificationCommandHandler_Handle_UnknownEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewSendEmailVerificationCommandHandler(mockRepo, mockEncoder, mockBus, 24*time.Hour, "https://app.example.com/verify-email")
+	ctx := context.Background()
+
+	mockRepo.On("FindByEmail", ctx, "unknown@example.com").
+		Return(nil, user_domain.NewUserNotFound("unknown@example.com"))
+
+	err := handler.Handle(ctx, &user_application.SendEmailVerificationCommand{Email: "unknown@example.com"})
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockBus.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+}
+
+func TestSendEmailVerificationCommandHandler_Handle_AlreadyVerified(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewSendEmailVerificationCommandHandler(mockRepo, mockEncoder, mockBus, 24*time.Hour, "https://app.example.com/verify-email")
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", EmailVerified: true}
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+	err := handler.Handle(ctx, &user_application.SendEmailVerificationCommand{Email: user.Email})
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockBus.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,28 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+)
+
+// SendEmailVerificationOnUserCreated asks new users to verify their email through cb, which should be a
+// durable transport, users created with an email already verified by their identity provider are skipped
+// by SendEmailVerificationCommand.
+type SendEmailVerificationOnUserCreated struct {
+	cb command.Bus
+}
+
+func NewSendEmailVerificationOnUserCreated(cb command.Bus) *SendEmailVerificationOnUserCreated {
+	return &SendEmailVerificationOnUserCreated{cb: cb}
+}
+
+func (sevuc SendEmailVerificationOnUserCreated) Handle(ctx context.Context, event bus.Dto) error {
+	userCreated, ok := event.(*user_domain.UserCreated)
+	if !ok {
+		return bus.NewInvalidDto("Invalid event")
+	}
+
+	return sevuc.cb.DispatchAsync(ctx, &SendEmailVerificationCommand{Email: userCreated.Email})
+}
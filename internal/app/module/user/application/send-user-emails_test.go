@@ -0,0 +1,64 @@
+package user_application_test
+
+import (
+	"context"
+	"testing"
+
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	user_infrastructure "github.com/mik3lon/starter-template/internal/app/module/user/infrastructure"
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendWelcomeEmailOnUserCreated_Handle(t *testing.T) {
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewSendWelcomeEmailOnUserCreated(mockBus)
+	ctx := context.Background()
+
+	mockBus.On("DispatchAsync", ctx, &mail.SendEmailCommand{
+		To:       "johndoe@example.com",
+		Template: user_application.WelcomeEmailTemplate,
+		Data:     map[string]string{"name": "John"},
+	}).Return(nil)
+
+	err := handler.Handle(ctx, &user_domain.UserCreated{Email: "johndoe@example.com", Name: "John"})
+
+	assert.NoError(t, err)
+	mockBus.AssertExpectations(t)
+}
+
+func TestSendEmailVerificationOnUserCreated_Handle(t *testing.T) {
+	mockBus := new(MockCommandBus)
+	handler := user_application.NewSendEmailVerificationOnUserCreated(mockBus)
+	ctx := context.Background()
+
+	mockBus.On("DispatchAsync", ctx, &user_application.SendEmailVerificationCommand{Email: "johndoe@example.com"}).Return(nil)
+
+	err := handler.Handle(ctx, &user_domain.UserCreated{Email: "johndoe@example.com", Name: "John"})
+
+	assert.NoError(t, err)
+	mockBus.AssertExpectations(t)
+}
+
+func TestUserEmailTemplatesRender(t *testing.T) {
+	templates, err := mail.NewTemplates("en", user_infrastructure.MailTemplates())
+	require.NoError(t, err)
+
+	data := map[string]string{"name": "John", "link": "https://app.example.com/?token=t", "expires_at": "2030-01-02 03:04 UTC"}
+	for _, name := range []string{
+		user_application.WelcomeEmailTemplate,
+		user_application.EmailVerificationEmailTemplate,
+		user_application.PasswordResetEmailTemplate,
+	} {
+		for _, locale := range []string{"en", "es"} {
+			message, err := templates.Render(name, locale, data)
+
+			require.NoError(t, err, "%s.%s", name, locale)
+			assert.NotEmpty(t, message.Subject, "%s.%s", name, locale)
+			assert.NotEmpty(t, message.Text, "%s.%s", name, locale)
+			assert.NotEmpty(t, message.HTML, "%s.%s", name, locale)
+		}
+	}
+}
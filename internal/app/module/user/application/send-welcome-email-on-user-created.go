@@ -0,0 +1,34 @@
+package user_application
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/mail"
+)
+
+const WelcomeEmailTemplate = "welcome"
+
+// SendWelcomeEmailOnUserCreated mails the welcome email through cb, which should be a durable transport
+// so the email survives restarts.
+type SendWelcomeEmailOnUserCreated struct {
+	cb command.Bus
+}
+
+func NewSendWelcomeEmailOnUserCreated(cb command.Bus) *SendWelcomeEmailOnUserCreated {
+	return &SendWelcomeEmailOnUserCreated{cb: cb}
+}
+
+func (sweuc SendWelcomeEmailOnUserCreated) Handle(ctx context.Context, event bus.Dto) error {
+	userCreated, ok := event.(*user_domain.UserCreated)
+	if !ok {
+		return bus.NewInvalidDto("Invalid event")
+	}
+
+	return sweuc.cb.DispatchAsync(ctx, &mail.SendEmailCommand{
+		To:       userCreated.Email,
+		Template: WelcomeEmailTemplate,
+		Data:     map[string]string{"name": userCreated.Name},
+	})
+}
@@ -2,20 +2,26 @@ package user_application
 
 import (
 	"context"
-	"errors"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"github.com/mik3lon/starter-template/pkg/bus"
 )
 
 type UpdateUserProfileCommand struct {
-	Email    string
-	Username string
+	Email    string `validate:"required,email"`
+	Username string `validate:"required"`
 	Name     string
 	Surname  string
 }
 
 func (c UpdateUserProfileCommand) Id() string {
-	return "find-user-query-handler"
+	return "update-user-profile-command"
+}
+
+func (c UpdateUserProfileCommand) OwnerEmail() string {
+	return c.Email
+}
+
+func (c UpdateUserProfileCommand) OwnerPermission() user_domain.Permission {
+	return user_domain.PermissionUsersWrite
 }
 
 type UpdateUserProfileCommandHandler struct {
@@ -26,12 +32,7 @@ func NewUpdateUserProfileCommandHandler(r user_domain.UserRepository) *UpdateUse
 	return &UpdateUserProfileCommandHandler{r: r}
 }
 
-func (uupch UpdateUserProfileCommandHandler) Handle(ctx context.Context, command bus.Dto) error {
-	c, ok := command.(*UpdateUserProfileCommand)
-	if !ok {
-		return errors.New("invalid command")
-	}
-
+func (uupch UpdateUserProfileCommandHandler) Handle(ctx context.Context, c *UpdateUserProfileCommand) error {
 	user, err := uupch.r.FindByEmail(ctx, c.Email)
 	if err != nil {
 		return err
@@ -56,20 +56,6 @@ func TestUpdateUserProfileCommandHandler_Handle(t *testing.T) {
 	}))
 }
 
-func TestUpdateUserProfileCommandHandler_Handle_InvalidCommand(t *testing.T) {
-	// Mock dependencies
-	mockRepo := new(MockUserRepository)
-
-	// Create the handler
-	handler := user_application.NewUpdateUserProfileCommandHandler(mockRepo)
-
-	// Act
-	err := handler.Handle(context.Background(), nil)
-
-	// Assert
-	assert.EqualError(t, err, "invalid command")
-}
-
 func TestUpdateUserProfileCommandHandler_Handle_UserNotFound(t *testing.T) {
 	// Mock dependencies
 	mockRepo := new(MockUserRepository)
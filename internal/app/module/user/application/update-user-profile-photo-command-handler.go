@@ -2,19 +2,29 @@ package user_application
 
 import (
 	"context"
-	"errors"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"github.com/mik3lon/starter-template/pkg/bus"
 	"github.com/mik3lon/starter-template/pkg/file"
 )
 
 type UpdateUserProfilePhotoCommand struct {
-	Email string
-	Image *file.FileInfo
+	Email string         `validate:"required,email"`
+	Image *file.FileInfo `validate:"required"`
 }
 
 func (c UpdateUserProfilePhotoCommand) Id() string {
-	return "find-user-query-handler"
+	return "update-user-profile-photo-command"
+}
+
+func (c UpdateUserProfilePhotoCommand) IdempotencyOwner() string {
+	return c.Email
+}
+
+func (c UpdateUserProfilePhotoCommand) OwnerEmail() string {
+	return c.Email
+}
+
+func (c UpdateUserProfilePhotoCommand) OwnerPermission() user_domain.Permission {
+	return user_domain.PermissionUsersWrite
 }
 
 type UpdateUserProfilePhotoCommandHandler struct {
@@ -26,12 +36,7 @@ func NewUpdateUserProfilePhotoCommandHandler(r user_domain.UserRepository, iu fi
 	return &UpdateUserProfilePhotoCommandHandler{r: r, iu: iu}
 }
 
-func (uupch UpdateUserProfilePhotoCommandHandler) Handle(ctx context.Context, command bus.Dto) error {
-	c, ok := command.(*UpdateUserProfilePhotoCommand)
-	if !ok {
-		return errors.New("invalid command")
-	}
-
+func (uupch UpdateUserProfilePhotoCommandHandler) Handle(ctx context.Context, c *UpdateUserProfilePhotoCommand) error {
 	user, err := uupch.r.FindByEmail(ctx, c.Email)
 	if err != nil {
 		return err
@@ -67,21 +67,6 @@ func TestUpdateUserProfilePhotoCommandHandler_Handle(t *testing.T) {
 	}))
 }
 
-func TestUpdateUserProfilePhotoCommandHandler_Handle_InvalidCommand(t *testing.T) {
-	// Mock dependencies
-	mockRepo := new(MockUserRepository)
-	mockUploader := new(MockImageUploader)
-
-	// Create the handler
-	handler := user_application.NewUpdateUserProfilePhotoCommandHandler(mockRepo, mockUploader)
-
-	// Act
-	err := handler.Handle(context.Background(), nil)
-
-	// Assert
-	assert.EqualError(t, err, "invalid command")
-}
-
 func TestUpdateUserProfilePhotoCommandHandler_Handle_UserNotFound(t *testing.T) {
 	// Mock dependencies
 	mockRepo := new(MockUserRepository)
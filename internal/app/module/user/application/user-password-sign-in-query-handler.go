@@ -2,40 +2,32 @@ package user_application
 
 import (
 	"context"
-	"errors"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"github.com/mik3lon/starter-template/pkg/bus"
 )
 
 type UserPasswordSignInQuery struct {
-	Email    string
-	Password string
-}
-
-func (c UserPasswordSignInQuery) Id() string {
-	return "user-password-sign-in-query"
+	Email    string `validate:"required,email"`
+	Password string `validate:"required"`
 }
 
 type UserPasswordSignInQueryHandler struct {
 	r  user_domain.UserRepository
 	ue user_domain.UserEncoder
 	pe user_domain.PasswordEncrypter
+	// requireVerifiedEmail refuses to sign in users until they verify their email.
+	requireVerifiedEmail bool
 }
 
 func NewUserPasswordSignInQueryHandler(
 	r user_domain.UserRepository,
 	ue user_domain.UserEncoder,
 	pe user_domain.PasswordEncrypter,
+	requireVerifiedEmail bool,
 ) *UserPasswordSignInQueryHandler {
-	return &UserPasswordSignInQueryHandler{r: r, ue: ue, pe: pe}
+	return &UserPasswordSignInQueryHandler{r: r, ue: ue, pe: pe, requireVerifiedEmail: requireVerifiedEmail}
 }
 
-func (upsq UserPasswordSignInQueryHandler) Handle(ctx context.Context, c bus.Dto) (interface{}, error) {
-	cuc, ok := c.(*UserPasswordSignInQuery)
-	if !ok {
-		return nil, errors.New("invalid query")
-	}
-
+func (upsq UserPasswordSignInQueryHandler) Handle(ctx context.Context, cuc *UserPasswordSignInQuery) (*user_domain.TokenDetails, error) {
 	user, err := upsq.r.FindByEmail(ctx, cuc.Email)
 	if err != nil {
 		return nil, err
@@ -46,5 +38,10 @@ func (upsq UserPasswordSignInQueryHandler) Handle(ctx context.Context, c bus.Dto
 		return nil, err
 	}
 
+	// Checked after the password, so only the owner of the account learns it is not verified.
+	if upsq.requireVerifiedEmail && !user.EmailVerified {
+		return nil, user_domain.NewEmailNotVerified(user.Email)
+	}
+
 	return upsq.ue.GenerateToken(user)
 }
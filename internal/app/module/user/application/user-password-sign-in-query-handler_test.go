@@ -17,7 +17,7 @@ func TestUserPasswordSignInQueryHandler_Handle(t *testing.T) {
 	mockEncrypter := new(MockPasswordEncrypter)
 
 	// Create the handler
-	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter)
+	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter, false)
 
 	// Define inputs
 	query := &user_application.UserPasswordSignInQuery{
@@ -63,23 +63,6 @@ func TestUserPasswordSignInQueryHandler_Handle(t *testing.T) {
 	assert.Equal(t, tokenDetails, result)
 }
 
-func TestUserPasswordSignInQueryHandler_Handle_InvalidQuery(t *testing.T) {
-	// Mock dependencies
-	mockRepo := new(MockUserRepository)
-	mockEncoder := new(MockUserEncoder)
-	mockEncrypter := new(MockPasswordEncrypter)
-
-	// Create the handler
-	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter)
-
-	// Act
-	result, err := handler.Handle(context.Background(), nil)
-
-	// Assert
-	assert.EqualError(t, err, "invalid query")
-	assert.Nil(t, result)
-}
-
 func TestUserPasswordSignInQueryHandler_Handle_UserNotFound(t *testing.T) {
 	// Mock dependencies
 	mockRepo := new(MockUserRepository)
@@ -87,7 +70,7 @@ func TestUserPasswordSignInQueryHandler_Handle_UserNotFound(t *testing.T) {
 	mockEncrypter := new(MockPasswordEncrypter)
 
 	// Create the handler
-	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter)
+	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter, false)
 
 	// Define inputs
 	query := &user_application.UserPasswordSignInQuery{
@@ -116,7 +99,7 @@ func TestUserPasswordSignInQueryHandler_Handle_InvalidPassword(t *testing.T) {
 	mockEncrypter := new(MockPasswordEncrypter)
 
 	// Create the handler
-	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter)
+	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter, false)
 
 	// Define inputs
 	query := &user_application.UserPasswordSignInQuery{
@@ -153,7 +136,7 @@ func TestUserPasswordSignInQueryHandler_Handle_TokenGenerationError(t *testing.T
 	mockEncrypter := new(MockPasswordEncrypter)
 
 	// Create the handler
-	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter)
+	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter, false)
 
 	// Define inputs
 	query := &user_application.UserPasswordSignInQuery{
@@ -184,3 +167,24 @@ func TestUserPasswordSignInQueryHandler_Handle_TokenGenerationError(t *testing.T
 	mockEncrypter.AssertCalled(t, "VerifyPassword", existingUser.HashedPassword, query.Password)
 	mockEncoder.AssertCalled(t, "GenerateToken", existingUser)
 }
+
+func TestUserPasswordSignInQueryHandler_Handle_EmailNotVerified(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	mockEncrypter := new(MockPasswordEncrypter)
+	handler := user_application.NewUserPasswordSignInQueryHandler(mockRepo, mockEncoder, mockEncrypter, true)
+	ctx := context.Background()
+
+	existingUser := &user_domain.User{ID: "123", Email: "johndoe@example.com", HashedPassword: "hashedPassword123"}
+	mockRepo.On("FindByEmail", ctx, existingUser.Email).Return(existingUser, nil)
+	mockEncrypter.On("VerifyPassword", existingUser.HashedPassword, "password123").Return(nil)
+
+	result, err := handler.Handle(ctx, &user_application.UserPasswordSignInQuery{
+		Email:    existingUser.Email,
+		Password: "password123",
+	})
+
+	assert.Nil(t, result)
+	assert.IsType(t, &user_domain.EmailNotVerified{}, err)
+	mockEncoder.AssertNotCalled(t, "GenerateToken", existingUser)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"github.com/golang-jwt/jwt"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
 	"github.com/mik3lon/starter-template/pkg/file"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 // Mock dependencies
@@ -64,6 +67,80 @@ func (m *MockUserEncoder) GenerateToken(user *user_domain.User) (*user_domain.To
 	return args.Get(0).(*user_domain.TokenDetails), args.Error(1)
 }
 
+func (m *MockUserEncoder) GenerateEmailVerificationToken(user *user_domain.User, tokenId string, expiresAt time.Time) (string, error) {
+	args := m.Called(user, tokenId, expiresAt)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserEncoder) RotateToken(user *user_domain.User, familyId string) (*user_domain.TokenDetails, error) {
+	args := m.Called(user, familyId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*user_domain.TokenDetails), args.Error(1)
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Use(ctx context.Context, token *user_domain.RefreshToken, now time.Time) (bool, error) {
+	args := m.Called(ctx, token, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) IsFamilyRevoked(ctx context.Context, familyId string) (bool, error) {
+	args := m.Called(ctx, familyId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, token *user_domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	args := m.Called(ctx, now)
+	return args.Error(0)
+}
+
+type MockTokenRevocationStore struct {
+	mock.Mock
+}
+
+func (m *MockTokenRevocationStore) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	args := m.Called(ctx, tokenId, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationStore) RevokeIssuedBefore(ctx context.Context, email string, before time.Time) error {
+	args := m.Called(ctx, email, before)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationStore) IsRevoked(ctx context.Context, token *user_domain.IssuedToken) (bool, error) {
+	args := m.Called(ctx, token)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockPasswordResetTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetTokenRepository) Save(ctx context.Context, token *user_domain.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepository) Consume(ctx context.Context, hash string) (*user_domain.PasswordResetToken, error) {
+	args := m.Called(ctx, hash)
+	if token, ok := args.Get(0).(*user_domain.PasswordResetToken); ok {
+		return token, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 type MockPasswordEncrypter struct {
 	mock.Mock
 }
@@ -89,3 +166,36 @@ func (m *MockImageUploader) Upload(ctx context.Context, f file.FileInfo) (*file.
 	}
 	return nil, args.Error(1)
 }
+
+type MockCommandBus struct {
+	mock.Mock
+}
+
+func (m *MockCommandBus) RegisterCommand(c bus.Dto, handler command.CommandHandler) error {
+	args := m.Called(c, handler)
+	return args.Error(0)
+}
+
+func (m *MockCommandBus) Dispatch(ctx context.Context, dto bus.Dto) error {
+	args := m.Called(ctx, dto)
+	return args.Error(0)
+}
+
+func (m *MockCommandBus) DispatchAsync(ctx context.Context, dto bus.Dto) error {
+	args := m.Called(ctx, dto)
+	return args.Error(0)
+}
+
+func (m *MockCommandBus) DispatchAt(ctx context.Context, dto bus.Dto, at time.Time) error {
+	args := m.Called(ctx, dto, at)
+	return args.Error(0)
+}
+
+func (m *MockCommandBus) DispatchAfter(ctx context.Context, dto bus.Dto, delay time.Duration) error {
+	args := m.Called(ctx, dto, delay)
+	return args.Error(0)
+}
+
+func (m *MockCommandBus) ProcessFailed(ctx context.Context) {
+	m.Called(ctx)
+}
@@ -0,0 +1,50 @@
+package user_application
+
+import (
+	"context"
+	"errors"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+)
+
+type VerifyEmailCommand struct {
+	Token string `validate:"required"`
+}
+
+func (c VerifyEmailCommand) Id() string {
+	return "verify-email-command"
+}
+
+type VerifyEmailCommandHandler struct {
+	r  user_domain.UserRepository
+	ue user_domain.UserEncoder
+}
+
+func NewVerifyEmailCommandHandler(r user_domain.UserRepository, ue user_domain.UserEncoder) *VerifyEmailCommandHandler {
+	return &VerifyEmailCommandHandler{r: r, ue: ue}
+}
+
+func (vech VerifyEmailCommandHandler) Handle(ctx context.Context, c *VerifyEmailCommand) error {
+	claims, err := vech.ue.DecryptToken(c.Token)
+	if err != nil {
+		return user_domain.NewInvalidVerificationToken()
+	}
+
+	token, err := user_domain.NewEmailVerificationTokenFromClaims(claims)
+	if err != nil {
+		return err
+	}
+
+	user, err := vech.r.FindByEmail(ctx, token.Email)
+	if errors.As(err, new(*user_domain.UserNotFound)) {
+		return user_domain.NewInvalidVerificationToken()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := user.VerifyEmail(token.ID); err != nil {
+		return err
+	}
+
+	return vech.r.Save(ctx, user)
+}
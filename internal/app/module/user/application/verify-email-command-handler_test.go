@@ -0,0 +1,92 @@
+package user_application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func verificationClaims(tokenId string) jwt.MapClaims {
+	return jwt.MapClaims{
+		user_domain.TokenTypeClaim: user_domain.EmailVerificationTokenType,
+		"sub":                      "johndoe@example.com",
+		"jti":                      tokenId,
+		"exp":                      float64(4102444800),
+	}
+}
+
+func TestVerifyEmailCommandHandler_Handle(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewVerifyEmailCommandHandler(mockRepo, mockEncoder)
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", VerificationTokenId: "token-1"}
+	mockEncoder.On("DecryptToken", "verification-token").Return(verificationClaims("token-1"), nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+	mockRepo.On("Save", ctx, user).Return(nil)
+
+	err := handler.Handle(ctx, &user_application.VerifyEmailCommand{Token: "verification-token"})
+
+	assert.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+	assert.NotNil(t, user.EmailVerifiedAt)
+	assert.Empty(t, user.VerificationTokenId)
+	events := user.PullDomainEvents()
+	if assert.Len(t, events, 1) {
+		assert.IsType(t, &user_domain.UserEmailVerified{}, events[0])
+	}
+}
+
+func TestVerifyEmailCommandHandler_Handle_SupersededToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewVerifyEmailCommandHandler(mockRepo, mockEncoder)
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", VerificationTokenId: "token-2"}
+	mockEncoder.On("DecryptToken", "verification-token").Return(verificationClaims("token-1"), nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+	err := handler.Handle(ctx, &user_application.VerifyEmailCommand{Token: "verification-token"})
+
+	assert.IsType(t, &user_domain.InvalidVerificationToken{}, err)
+	assert.False(t, user.EmailVerified)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestVerifyEmailCommandHandler_Handle_AlreadyVerified(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewVerifyEmailCommandHandler(mockRepo, mockEncoder)
+	ctx := context.Background()
+
+	user := &user_domain.User{ID: "123", Email: "johndoe@example.com", EmailVerified: true}
+	mockEncoder.On("DecryptToken", "verification-token").Return(verificationClaims("token-1"), nil)
+	mockRepo.On("FindByEmail", ctx, user.Email).Return(user, nil)
+
+	err := handler.Handle(ctx, &user_application.VerifyEmailCommand{Token: "verification-token"})
+
+	assert.IsType(t, &user_domain.EmailAlreadyVerified{}, err)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestVerifyEmailCommandHandler_Handle_NotAVerificationToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEncoder := new(MockUserEncoder)
+	handler := user_application.NewVerifyEmailCommandHandler(mockRepo, mockEncoder)
+
+	claims := verificationClaims("token-1")
+	claims[user_domain.TokenTypeClaim] = user_domain.AccessTokenType
+	mockEncoder.On("DecryptToken", "access-token").Return(claims, nil)
+
+	err := handler.Handle(context.Background(), &user_application.VerifyEmailCommand{Token: "access-token"})
+
+	assert.IsType(t, &user_domain.InvalidVerificationToken{}, err)
+	mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+}
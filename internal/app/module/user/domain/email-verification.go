@@ -0,0 +1,91 @@
+package user_domain
+
+import (
+	"github.com/golang-jwt/jwt"
+	"time"
+)
+
+// EmailVerificationTokenType is the TokenTypeClaim of the tokens verifying the email of a user.
+const EmailVerificationTokenType = "email_verification"
+
+// EmailVerificationToken proves the ownership of an email, only the last one issued to a user verifies it.
+type EmailVerificationToken struct {
+	ID        string
+	Email     string
+	ExpiresAt time.Time
+}
+
+// NewEmailVerificationTokenFromClaims reads the email verification token of verified claims, failing for
+// any other token.
+func NewEmailVerificationTokenFromClaims(claims jwt.Claims) (*EmailVerificationToken, error) {
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok || mapClaims[TokenTypeClaim] != EmailVerificationTokenType {
+		return nil, NewInvalidVerificationToken()
+	}
+
+	id, _ := mapClaims["jti"].(string)
+	email, _ := mapClaims["sub"].(string)
+	expiresAt, _ := mapClaims["exp"].(float64)
+	if id == "" || email == "" {
+		return nil, NewInvalidVerificationToken()
+	}
+
+	return &EmailVerificationToken{ID: id, Email: email, ExpiresAt: time.Unix(int64(expiresAt), 0)}, nil
+}
+
+type InvalidVerificationToken struct {
+	extraItems map[string]interface{}
+}
+
+func NewInvalidVerificationToken() *InvalidVerificationToken {
+	return &InvalidVerificationToken{extraItems: map[string]interface{}{}}
+}
+
+func (i InvalidVerificationToken) Error() string {
+	return "invalid verification token"
+}
+
+func (i InvalidVerificationToken) ExtraItems() map[string]interface{} {
+	return i.extraItems
+}
+
+type EmailAlreadyVerified struct {
+	extraItems map[string]interface{}
+}
+
+func NewEmailAlreadyVerified(email string) *EmailAlreadyVerified {
+	return &EmailAlreadyVerified{
+		extraItems: map[string]interface{}{
+			"email": email,
+		},
+	}
+}
+
+func (e EmailAlreadyVerified) Error() string {
+	return "email already verified"
+}
+
+func (e EmailAlreadyVerified) ExtraItems() map[string]interface{} {
+	return e.extraItems
+}
+
+// EmailNotVerified is a sign in refused until the user verifies its email.
+type EmailNotVerified struct {
+	extraItems map[string]interface{}
+}
+
+func NewEmailNotVerified(email string) *EmailNotVerified {
+	return &EmailNotVerified{
+		extraItems: map[string]interface{}{
+			"email": email,
+		},
+	}
+}
+
+func (e EmailNotVerified) Error() string {
+	return "email not verified"
+}
+
+func (e EmailNotVerified) ExtraItems() map[string]interface{} {
+	return e.extraItems
+}
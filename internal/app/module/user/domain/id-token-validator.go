@@ -8,6 +8,8 @@ type IdTokenClaims struct {
 	Username          string // Full name
 	Email             string
 	ProfilePictureUrl string
+	// EmailVerified tells whether the identity provider verified the ownership of Email.
+	EmailVerified bool
 }
 
 func NewIdTokenClaims(name string, surname string, username string, email string, profilePictureUrl string) *IdTokenClaims {
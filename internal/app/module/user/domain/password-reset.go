@@ -0,0 +1,81 @@
+package user_domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// PasswordResetToken lets the owner of an email choose a new password once. Only the hash of the token
+// sent to the user is stored, so a leaked store resets no passwords.
+type PasswordResetToken struct {
+	Hash      string
+	UserEmail string
+	ExpiresAt time.Time
+}
+
+// NewPasswordResetToken returns the token to send to the user along with its stored counterpart.
+func NewPasswordResetToken(email string, expiresAt time.Time) (string, *PasswordResetToken, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+
+	return token, &PasswordResetToken{Hash: HashPasswordResetToken(token), UserEmail: email, ExpiresAt: expiresAt}, nil
+}
+
+func HashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+type PasswordResetTokenRepository interface {
+	// Save stores token, invalidating the previous tokens of its user.
+	Save(ctx context.Context, token *PasswordResetToken) error
+	// Consume removes the token with hash and returns it, or nil when there is none, so it is used only once.
+	Consume(ctx context.Context, hash string) (*PasswordResetToken, error)
+}
+
+type InvalidPasswordResetToken struct {
+	extraItems map[string]interface{}
+}
+
+func NewInvalidPasswordResetToken() *InvalidPasswordResetToken {
+	return &InvalidPasswordResetToken{extraItems: map[string]interface{}{}}
+}
+
+func (i InvalidPasswordResetToken) Error() string {
+	return "invalid password reset token"
+}
+
+func (i InvalidPasswordResetToken) ExtraItems() map[string]interface{} {
+	return i.extraItems
+}
+
+type WrongPassword struct {
+	extraItems map[string]interface{}
+}
+
+func NewWrongPassword(email string) *WrongPassword {
+	return &WrongPassword{
+		extraItems: map[string]interface{}{
+			"email": email,
+		},
+	}
+}
+
+func (w WrongPassword) Error() string {
+	return "wrong current password"
+}
+
+func (w WrongPassword) ExtraItems() map[string]interface{} {
+	return w.extraItems
+}
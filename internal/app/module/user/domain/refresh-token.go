@@ -0,0 +1,93 @@
+package user_domain
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt"
+	"time"
+)
+
+const (
+	// TokenTypeClaim tells access tokens from refresh tokens, so neither is accepted in place of the other.
+	TokenTypeClaim   = "typ"
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+	// TokenFamilyClaim groups the refresh tokens rotated from the same sign in.
+	TokenFamilyClaim = "fam"
+)
+
+// RefreshToken is a one time use refresh token, presenting it again revokes its whole family.
+type RefreshToken struct {
+	ID        string
+	FamilyId  string
+	UserEmail string
+	ExpiresAt time.Time
+}
+
+// NewRefreshTokenFromClaims reads the refresh token of verified claims, failing for any other token.
+func NewRefreshTokenFromClaims(claims jwt.Claims) (*RefreshToken, error) {
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok || mapClaims[TokenTypeClaim] != RefreshTokenType {
+		return nil, NewInvalidRefreshToken()
+	}
+
+	id, _ := mapClaims["jti"].(string)
+	familyId, _ := mapClaims[TokenFamilyClaim].(string)
+	email, _ := mapClaims["sub"].(string)
+	expiresAt, _ := mapClaims["exp"].(float64)
+	if id == "" || familyId == "" || email == "" {
+		return nil, NewInvalidRefreshToken()
+	}
+
+	return &RefreshToken{
+		ID:        id,
+		FamilyId:  familyId,
+		UserEmail: email,
+		ExpiresAt: time.Unix(int64(expiresAt), 0),
+	}, nil
+}
+
+type RefreshTokenRepository interface {
+	// Use records token as used at now, returning false when it was already used.
+	Use(ctx context.Context, token *RefreshToken, now time.Time) (bool, error)
+	IsFamilyRevoked(ctx context.Context, familyId string) (bool, error)
+	RevokeFamily(ctx context.Context, token *RefreshToken) error
+	// PurgeExpired forgets the used tokens expired before now, they can not be presented anymore.
+	PurgeExpired(ctx context.Context, now time.Time) error
+}
+
+type InvalidRefreshToken struct {
+	extraItems map[string]interface{}
+}
+
+func NewInvalidRefreshToken() *InvalidRefreshToken {
+	return &InvalidRefreshToken{extraItems: map[string]interface{}{}}
+}
+
+func (i InvalidRefreshToken) Error() string {
+	return "invalid refresh token"
+}
+
+func (i InvalidRefreshToken) ExtraItems() map[string]interface{} {
+	return i.extraItems
+}
+
+// RefreshTokenReused is a refresh token presented twice, likely stolen, its family is revoked.
+type RefreshTokenReused struct {
+	extraItems map[string]interface{}
+}
+
+func NewRefreshTokenReused(familyId string) *RefreshTokenReused {
+	return &RefreshTokenReused{
+		extraItems: map[string]interface{}{
+			"family_id": familyId,
+		},
+	}
+}
+
+func (r RefreshTokenReused) Error() string {
+	return "refresh token already used"
+}
+
+func (r RefreshTokenReused) ExtraItems() map[string]interface{} {
+	return r.extraItems
+}
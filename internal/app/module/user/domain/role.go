@@ -0,0 +1,87 @@
+package user_domain
+
+import (
+	"context"
+	"strings"
+)
+
+// Role is what a user is allowed to do, through the permissions granted to it.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+const (
+	// UserIdClaim and RoleClaim carry the user authenticated by an access token.
+	UserIdClaim = "uid"
+	RoleClaim   = "role"
+)
+
+// NewRole parses raw, accepting the legacy ROLE_USER and ROL_USER spellings. An empty raw is a RoleUser.
+func NewRole(raw string) (Role, error) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	normalized = strings.TrimPrefix(normalized, "role_")
+	normalized = strings.TrimPrefix(normalized, "rol_")
+
+	switch Role(normalized) {
+	case "", RoleUser:
+		return RoleUser, nil
+	case RoleAdmin:
+		return RoleAdmin, nil
+	default:
+		return "", NewInvalidRole(raw)
+	}
+}
+
+// Permission is an action on a resource, named `resource:action`.
+type Permission string
+
+const (
+	PermissionProfileRead  Permission = "profile:read"
+	PermissionProfileWrite Permission = "profile:write"
+	PermissionUsersRead    Permission = "users:read"
+	PermissionUsersWrite   Permission = "users:write"
+	PermissionBusRead      Permission = "bus:read"
+	PermissionBusWrite     Permission = "bus:write"
+	// PermissionAll grants every permission, `resource:*` every action on a resource.
+	PermissionAll Permission = "*"
+)
+
+// Grants tells whether any of granted allows required, honouring the `*` and `resource:*` wildcards.
+func Grants(granted []Permission, required Permission) bool {
+	resource, _, _ := strings.Cut(string(required), ":")
+	for _, permission := range granted {
+		if permission == required || permission == PermissionAll || permission == Permission(resource+":*") {
+			return true
+		}
+	}
+
+	return false
+}
+
+type RolePermissionRepository interface {
+	// PermissionsOf returns the permissions granted to role, none for unknown roles.
+	PermissionsOf(ctx context.Context, role Role) ([]Permission, error)
+}
+
+type InvalidRole struct {
+	extraItems map[string]interface{}
+}
+
+func NewInvalidRole(role string) *InvalidRole {
+	return &InvalidRole{
+		extraItems: map[string]interface{}{
+			"role": role,
+		},
+	}
+}
+
+func (i InvalidRole) Error() string {
+	return "invalid role"
+}
+
+func (i InvalidRole) ExtraItems() map[string]interface{} {
+	return i.extraItems
+}
@@ -0,0 +1,76 @@
+package user_domain
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt"
+	"math"
+	"time"
+)
+
+const (
+	AccessTokenTTL  = 2 * time.Hour
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// IssuedAtClaim is the iat claim of a token issued at t. It keeps microseconds, so a token issued
+// right after a RevokeIssuedBefore watermark is not taken for one issued within the same second.
+func IssuedAtClaim(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+// IssuedToken identifies a signed token, access or refresh, so it can be revoked before it expires.
+type IssuedToken struct {
+	ID        string
+	UserEmail string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// NewIssuedTokenFromClaims reads the token of verified claims, failing for tokens without jti or sub.
+func NewIssuedTokenFromClaims(claims jwt.Claims) (*IssuedToken, error) {
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return nil, NewInvalidToken()
+	}
+
+	id, _ := mapClaims["jti"].(string)
+	email, _ := mapClaims["sub"].(string)
+	issuedAt, _ := mapClaims["iat"].(float64)
+	expiresAt, _ := mapClaims["exp"].(float64)
+	if id == "" || email == "" {
+		return nil, NewInvalidToken()
+	}
+
+	return &IssuedToken{
+		ID:        id,
+		UserEmail: email,
+		IssuedAt:  time.UnixMicro(int64(math.Round(issuedAt * 1e6))),
+		ExpiresAt: time.Unix(int64(expiresAt), 0),
+	}, nil
+}
+
+// TokenRevocationStore keeps the tokens revoked before their expiration, entries are only kept until
+// the tokens they revoke would have expired anyway.
+type TokenRevocationStore interface {
+	// Revoke rejects the token identified by tokenId until expiresAt.
+	Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error
+	// RevokeIssuedBefore rejects every token of email issued up to before, signing out all its devices.
+	RevokeIssuedBefore(ctx context.Context, email string, before time.Time) error
+	IsRevoked(ctx context.Context, token *IssuedToken) (bool, error)
+}
+
+type InvalidToken struct {
+	extraItems map[string]interface{}
+}
+
+func NewInvalidToken() *InvalidToken {
+	return &InvalidToken{extraItems: map[string]interface{}{}}
+}
+
+func (i InvalidToken) Error() string {
+	return "invalid token"
+}
+
+func (i InvalidToken) ExtraItems() map[string]interface{} {
+	return i.extraItems
+}
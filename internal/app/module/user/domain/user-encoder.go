@@ -1,8 +1,16 @@
 package user_domain
 
-import "github.com/golang-jwt/jwt"
+import (
+	"github.com/golang-jwt/jwt"
+	"time"
+)
 
 type UserEncoder interface {
+	// GenerateToken issues the tokens of a new sign in, starting a refresh token family.
 	GenerateToken(user *User) (*TokenDetails, error)
+	// RotateToken issues the tokens replacing a used refresh token of familyId.
+	RotateToken(user *User, familyId string) (*TokenDetails, error)
+	// GenerateEmailVerificationToken issues the token identified by tokenId verifying the email of user.
+	GenerateEmailVerificationToken(user *User, tokenId string, expiresAt time.Time) (string, error)
 	DecryptToken(tokenString string) (jwt.Claims, error)
 }
@@ -0,0 +1,91 @@
+package user_domain
+
+import "time"
+
+// DomainEvent is an event recorded by the user aggregate, Id names it once it is published. The domain
+// does not depend on the buses, the repositories hand the events over to them.
+type DomainEvent interface {
+	Id() string
+}
+
+type UserCreated struct {
+	UserId     string
+	Email      string
+	Username   string
+	Name       string
+	OccurredOn time.Time
+}
+
+func (e UserCreated) Id() string {
+	return "user-created"
+}
+
+type UserProfileUpdated struct {
+	UserId     string
+	Email      string
+	Username   string
+	Name       string
+	Surname    string
+	OccurredOn time.Time
+}
+
+func (e UserProfileUpdated) Id() string {
+	return "user-profile-updated"
+}
+
+type UserProfilePhotoChanged struct {
+	UserId            string
+	Email             string
+	ProfilePictureUrl string
+	OccurredOn        time.Time
+}
+
+func (e UserProfilePhotoChanged) Id() string {
+	return "user-profile-photo-changed"
+}
+
+// EmailVerificationRequested tells a verification token was issued. The token itself is never part of
+// the event, events are stored and published, it is mailed straight to the user instead.
+type EmailVerificationRequested struct {
+	UserId     string
+	Email      string
+	ExpiresAt  time.Time
+	OccurredOn time.Time
+}
+
+func (e EmailVerificationRequested) Id() string {
+	return "email-verification-requested"
+}
+
+type UserEmailVerified struct {
+	UserId     string
+	Email      string
+	OccurredOn time.Time
+}
+
+func (e UserEmailVerified) Id() string {
+	return "user-email-verified"
+}
+
+// PasswordResetRequested tells a password reset token was issued, which like verification tokens is only
+// ever mailed to the user.
+type PasswordResetRequested struct {
+	UserId     string
+	Email      string
+	ExpiresAt  time.Time
+	OccurredOn time.Time
+}
+
+func (e PasswordResetRequested) Id() string {
+	return "password-reset-requested"
+}
+
+type UserPasswordChanged struct {
+	UserId     string
+	Email      string
+	OccurredOn time.Time
+}
+
+func (e UserPasswordChanged) Id() string {
+	return "user-password-changed"
+}
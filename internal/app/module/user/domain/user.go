@@ -1,37 +1,133 @@
 package user_domain
 
-import (
-	"time"
-)
+import "time"
 
 type UserList []*User
 
 type User struct {
-	ID                string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	Username          string    `gorm:"type:varchar(50);uniqueIndex"`
-	Email             string    `gorm:"type:varchar(100);uniqueIndex"`
-	HashedPassword    string    `gorm:"type:varchar(255)"`
-	Name              string    `gorm:"type:varchar(50)"`
-	Surname           string    `gorm:"type:varchar(50)"`
-	Role              string    `gorm:"type:varchar(20);default:'user'"`
-	ProfilePictureUrl string    `gorm:"type:varchar(200)"`
-	CreatedAt         time.Time `gorm:"autoCreateTime"`
-	UpdatedAt         time.Time `gorm:"autoUpdateTime"`
+	ID                string `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Username          string `gorm:"type:varchar(50);uniqueIndex"`
+	Email             string `gorm:"type:varchar(100);uniqueIndex"`
+	HashedPassword    string `gorm:"type:varchar(255)"`
+	Name              string `gorm:"type:varchar(50)"`
+	Surname           string `gorm:"type:varchar(50)"`
+	Role              Role   `gorm:"type:varchar(20);default:'user'"`
+	ProfilePictureUrl string `gorm:"type:varchar(200)"`
+	EmailVerified     bool   `gorm:"default:false"`
+	EmailVerifiedAt   *time.Time
+	// VerificationTokenId is the id of the last email verification token issued, the only one accepted.
+	VerificationTokenId string    `gorm:"type:varchar(36)"`
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime"`
+
+	domainEvents []DomainEvent
 }
 
 func (u *User) UpdateProfile(username string, name string, surname string) {
 	u.Username = username
 	u.Name = name
 	u.Surname = surname
+
+	u.record(&UserProfileUpdated{
+		UserId:     u.ID,
+		Email:      u.Email,
+		Username:   username,
+		Name:       name,
+		Surname:    surname,
+		OccurredOn: time.Now(),
+	})
 }
 
 func (u *User) UpdateProfilePhoto(image string) {
 	u.ProfilePictureUrl = image
+
+	u.record(&UserProfilePhotoChanged{
+		UserId:            u.ID,
+		Email:             u.Email,
+		ProfilePictureUrl: image,
+		OccurredOn:        time.Now(),
+	})
+}
+
+// RequestEmailVerification makes the token identified by tokenId the only one verifying the email.
+func (u *User) RequestEmailVerification(tokenId string, expiresAt time.Time) {
+	u.VerificationTokenId = tokenId
+
+	u.record(&EmailVerificationRequested{
+		UserId:     u.ID,
+		Email:      u.Email,
+		ExpiresAt:  expiresAt,
+		OccurredOn: time.Now(),
+	})
+}
+
+// VerifyEmail verifies the email with the token identified by tokenId, which can not be used again.
+func (u *User) VerifyEmail(tokenId string) error {
+	if u.EmailVerified {
+		return NewEmailAlreadyVerified(u.Email)
+	}
+	if u.VerificationTokenId == "" || u.VerificationTokenId != tokenId {
+		return NewInvalidVerificationToken()
+	}
+
+	u.MarkEmailVerified()
+
+	return nil
+}
+
+// MarkEmailVerified verifies the email without a token, for emails a trusted identity provider verified.
+func (u *User) MarkEmailVerified() {
+	if u.EmailVerified {
+		return
+	}
+
+	now := time.Now()
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &now
+	u.VerificationTokenId = ""
+
+	u.record(&UserEmailVerified{
+		UserId:     u.ID,
+		Email:      u.Email,
+		OccurredOn: now,
+	})
+}
+
+// RequestPasswordReset records a token to choose a new password with was issued until expiresAt.
+func (u *User) RequestPasswordReset(expiresAt time.Time) {
+	u.record(&PasswordResetRequested{
+		UserId:     u.ID,
+		Email:      u.Email,
+		ExpiresAt:  expiresAt,
+		OccurredOn: time.Now(),
+	})
+}
+
+func (u *User) ChangePassword(hashedPassword string) {
+	u.HashedPassword = hashedPassword
+
+	u.record(&UserPasswordChanged{
+		UserId:     u.ID,
+		Email:      u.Email,
+		OccurredOn: time.Now(),
+	})
+}
+
+// PullDomainEvents returns the events recorded since the last pull and forgets them.
+func (u *User) PullDomainEvents() []DomainEvent {
+	events := u.domainEvents
+	u.domainEvents = nil
+
+	return events
+}
+
+func (u *User) record(event DomainEvent) {
+	u.domainEvents = append(u.domainEvents, event)
 }
 
 // CreateUser creates a new User entity.
-func CreateUser(id, username, email, password, name, surname, role, profilePictureUrl string) *User {
-	return &User{
+func CreateUser(id, username, email, password, name, surname string, role Role, profilePictureUrl string) *User {
+	u := &User{
 		ID:                id,
 		Username:          username,
 		Email:             email,
@@ -43,6 +139,16 @@ func CreateUser(id, username, email, password, name, surname, role, profilePictu
 		CreatedAt:         time.Now(),
 		UpdatedAt:         time.Now(),
 	}
+
+	u.record(&UserCreated{
+		UserId:     id,
+		Email:      email,
+		Username:   username,
+		Name:       name,
+		OccurredOn: u.CreatedAt,
+	})
+
+	return u
 }
 
 func FromPrimitives(
@@ -51,8 +157,8 @@ func FromPrimitives(
 	email,
 	hashedPassword,
 	name,
-	surname,
-	role,
+	surname string,
+	role Role,
 	profilePictureUrl string,
 	createdAt, updatedAt time.Time,
 ) *User {
@@ -0,0 +1,49 @@
+package user_infrastructure
+
+import (
+	"context"
+	"fmt"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"strings"
+)
+
+// ConfigRolePermissionRepository is a RolePermissionRepository whose permissions are fixed at startup.
+type ConfigRolePermissionRepository struct {
+	permissions map[user_domain.Role][]user_domain.Permission
+}
+
+func NewConfigRolePermissionRepository(permissions map[user_domain.Role][]user_domain.Permission) *ConfigRolePermissionRepository {
+	return &ConfigRolePermissionRepository{permissions: permissions}
+}
+
+func (r *ConfigRolePermissionRepository) PermissionsOf(ctx context.Context, role user_domain.Role) ([]user_domain.Permission, error) {
+	return r.permissions[role], nil
+}
+
+// ParseRolePermissions parses roles separated by `;`, each one as `role=permission,permission`, such as
+// `user=profile:read,profile:write;admin=*`.
+func ParseRolePermissions(raw string) (map[user_domain.Role][]user_domain.Permission, error) {
+	permissions := make(map[user_domain.Role][]user_domain.Permission)
+	for _, entry := range strings.Split(raw, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		rawRole, rawPermissions, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("role permissions %q: missing =", entry)
+		}
+		role, err := user_domain.NewRole(rawRole)
+		if err != nil {
+			return nil, fmt.Errorf("role permissions %q: %w", entry, err)
+		}
+
+		for _, permission := range strings.Split(rawPermissions, ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				permissions[role] = append(permissions[role], user_domain.Permission(permission))
+			}
+		}
+	}
+
+	return permissions, nil
+}
@@ -29,6 +29,14 @@ func (gv *GoogleIDTokenValidator) Validate(ctx context.Context, idToken string)
 		ProfilePictureUrl: payload["picture"].(string),
 	}
 
+	// Google sends email_verified as a boolean, some older tokens as a string.
+	switch emailVerified := payload["email_verified"].(type) {
+	case bool:
+		idTokenClaims.EmailVerified = emailVerified
+	case string:
+		idTokenClaims.EmailVerified = emailVerified == "true"
+	}
+
 	if familyName, ok := payload["family_name"].(string); ok {
 		idTokenClaims.Surname = familyName
 	} else {
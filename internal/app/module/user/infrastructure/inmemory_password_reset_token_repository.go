@@ -0,0 +1,44 @@
+package user_infrastructure
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"sync"
+)
+
+// InMemoryPasswordResetTokenRepository is an in-memory implementation of PasswordResetTokenRepository.
+type InMemoryPasswordResetTokenRepository struct {
+	tokens map[string]*user_domain.PasswordResetToken
+	lock   sync.Mutex
+}
+
+func NewInMemoryPasswordResetTokenRepository() *InMemoryPasswordResetTokenRepository {
+	return &InMemoryPasswordResetTokenRepository{tokens: make(map[string]*user_domain.PasswordResetToken)}
+}
+
+func (r *InMemoryPasswordResetTokenRepository) Save(ctx context.Context, token *user_domain.PasswordResetToken) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for hash, previous := range r.tokens {
+		if previous.UserEmail == token.UserEmail {
+			delete(r.tokens, hash)
+		}
+	}
+	r.tokens[token.Hash] = token
+
+	return nil
+}
+
+func (r *InMemoryPasswordResetTokenRepository) Consume(ctx context.Context, hash string) (*user_domain.PasswordResetToken, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	token, ok := r.tokens[hash]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.tokens, hash)
+
+	return token, nil
+}
@@ -0,0 +1,65 @@
+package user_infrastructure
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"sync"
+	"time"
+)
+
+type usedRefreshToken struct {
+	usedAt    time.Time
+	expiresAt time.Time
+}
+
+// InMemoryRefreshTokenRepository is an in-memory implementation of RefreshTokenRepository.
+type InMemoryRefreshTokenRepository struct {
+	used            map[string]usedRefreshToken
+	revokedFamilies map[string]bool
+	lock            sync.Mutex
+}
+
+func NewInMemoryRefreshTokenRepository() *InMemoryRefreshTokenRepository {
+	return &InMemoryRefreshTokenRepository{used: make(map[string]usedRefreshToken), revokedFamilies: make(map[string]bool)}
+}
+
+func (r *InMemoryRefreshTokenRepository) Use(ctx context.Context, token *user_domain.RefreshToken, now time.Time) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.used[token.ID]; ok {
+		return false, nil
+	}
+	r.used[token.ID] = usedRefreshToken{usedAt: now, expiresAt: token.ExpiresAt}
+
+	return true, nil
+}
+
+func (r *InMemoryRefreshTokenRepository) IsFamilyRevoked(ctx context.Context, familyId string) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.revokedFamilies[familyId], nil
+}
+
+func (r *InMemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, token *user_domain.RefreshToken) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.revokedFamilies[token.FamilyId] = true
+
+	return nil
+}
+
+func (r *InMemoryRefreshTokenRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for id, used := range r.used {
+		if used.expiresAt.Before(now) {
+			delete(r.used, id)
+		}
+	}
+
+	return nil
+}
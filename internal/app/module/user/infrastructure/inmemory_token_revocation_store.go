@@ -0,0 +1,65 @@
+package user_infrastructure
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"sync"
+	"time"
+)
+
+// InMemoryTokenRevocationStore is a TokenRevocationStore that does not survive restarts nor is shared
+// between instances, meant for tests and local runs.
+type InMemoryTokenRevocationStore struct {
+	revoked      map[string]time.Time
+	issuedBefore map[string]time.Time
+	lock         sync.Mutex
+}
+
+func NewInMemoryTokenRevocationStore() *InMemoryTokenRevocationStore {
+	return &InMemoryTokenRevocationStore{
+		revoked:      make(map[string]time.Time),
+		issuedBefore: make(map[string]time.Time),
+	}
+}
+
+func (s *InMemoryTokenRevocationStore) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.evictExpired()
+	s.revoked[tokenId] = expiresAt
+
+	return nil
+}
+
+func (s *InMemoryTokenRevocationStore) RevokeIssuedBefore(ctx context.Context, email string, before time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if before.After(s.issuedBefore[email]) {
+		s.issuedBefore[email] = before
+	}
+
+	return nil
+}
+
+func (s *InMemoryTokenRevocationStore) IsRevoked(ctx context.Context, token *user_domain.IssuedToken) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.revoked[token.ID]; ok {
+		return true, nil
+	}
+	before, ok := s.issuedBefore[token.UserEmail]
+
+	return ok && !token.IssuedAt.After(before), nil
+}
+
+func (s *InMemoryTokenRevocationStore) evictExpired() {
+	now := time.Now()
+	for tokenId, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, tokenId)
+		}
+	}
+}
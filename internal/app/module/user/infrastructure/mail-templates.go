@@ -0,0 +1,19 @@
+package user_infrastructure
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed mail-templates
+var mailTemplates embed.FS
+
+// MailTemplates returns the templates of the emails sent to users, see mail.Templates.
+func MailTemplates() fs.FS {
+	templates, err := fs.Sub(mailTemplates, "mail-templates")
+	if err != nil {
+		panic(err)
+	}
+
+	return templates
+}
@@ -0,0 +1,71 @@
+package user_infrastructure
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type passwordResetTokenRecord struct {
+	Hash      string    `gorm:"type:varchar(64);primaryKey"`
+	UserEmail string    `gorm:"type:varchar(100);index"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (passwordResetTokenRecord) TableName() string {
+	return "password_reset_tokens"
+}
+
+// PostgresPasswordResetTokenRepository is a Postgres implementation of PasswordResetTokenRepository using Gorm.
+type PostgresPasswordResetTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewPostgresPasswordResetTokenRepository(db *gorm.DB) (*PostgresPasswordResetTokenRepository, error) {
+	if err := db.AutoMigrate(&passwordResetTokenRecord{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresPasswordResetTokenRepository{DB: db}, nil
+}
+
+func (r *PostgresPasswordResetTokenRepository) Save(ctx context.Context, token *user_domain.PasswordResetToken) error {
+	return transaction.DB(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&passwordResetTokenRecord{}, "user_email = ?", token.UserEmail).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&passwordResetTokenRecord{
+			Hash:      token.Hash,
+			UserEmail: token.UserEmail,
+			ExpiresAt: token.ExpiresAt,
+		}).Error
+	})
+}
+
+func (r *PostgresPasswordResetTokenRepository) Consume(ctx context.Context, hash string) (*user_domain.PasswordResetToken, error) {
+	var records []passwordResetTokenRecord
+
+	// Deleting and returning in one statement lets only one of concurrent resets consume the token.
+	err := transaction.DB(ctx, r.DB).
+		Clauses(clause.Returning{}).
+		Where("hash = ?", hash).
+		Delete(&records).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	return &user_domain.PasswordResetToken{
+		Hash:      records[0].Hash,
+		UserEmail: records[0].UserEmail,
+		ExpiresAt: records[0].ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package user_infrastructure
+
+import (
+	"context"
+	"errors"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type refreshTokenFamilyRecord struct {
+	ID        string `gorm:"type:uuid;primaryKey"`
+	UserEmail string `gorm:"type:varchar(100);index"`
+	RevokedAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (refreshTokenFamilyRecord) TableName() string {
+	return "refresh_token_families"
+}
+
+type usedRefreshTokenRecord struct {
+	ID        string    `gorm:"type:uuid;primaryKey"`
+	FamilyId  string    `gorm:"type:uuid;index"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    time.Time
+}
+
+func (usedRefreshTokenRecord) TableName() string {
+	return "used_refresh_tokens"
+}
+
+// PostgresRefreshTokenRepository is a Postgres implementation of RefreshTokenRepository using Gorm.
+// Families are stored the first time one of their tokens is used or revoked.
+type PostgresRefreshTokenRepository struct {
+	DB *gorm.DB
+}
+
+func NewPostgresRefreshTokenRepository(db *gorm.DB) (*PostgresRefreshTokenRepository, error) {
+	if err := db.AutoMigrate(&refreshTokenFamilyRecord{}, &usedRefreshTokenRecord{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresRefreshTokenRepository{DB: db}, nil
+}
+
+func (r *PostgresRefreshTokenRepository) Use(ctx context.Context, token *user_domain.RefreshToken, now time.Time) (bool, error) {
+	first := false
+	err := transaction.DB(ctx, r.DB).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&refreshTokenFamilyRecord{ID: token.FamilyId, UserEmail: token.UserEmail}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		// Concurrent uses of the same token conflict here, only one of them is the first.
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&usedRefreshTokenRecord{
+			ID:        token.ID,
+			FamilyId:  token.FamilyId,
+			ExpiresAt: token.ExpiresAt,
+			UsedAt:    now,
+		})
+		first = result.RowsAffected == 1
+
+		return result.Error
+	})
+
+	return first, err
+}
+
+func (r *PostgresRefreshTokenRepository) IsFamilyRevoked(ctx context.Context, familyId string) (bool, error) {
+	var family refreshTokenFamilyRecord
+	err := transaction.DB(ctx, r.DB).Take(&family, "id = ?", familyId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return family.RevokedAt != nil, nil
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, token *user_domain.RefreshToken) error {
+	now := time.Now()
+
+	return transaction.DB(ctx, r.DB).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"revoked_at": gorm.Expr("COALESCE(refresh_token_families.revoked_at, ?)", now)}),
+	}).Create(&refreshTokenFamilyRecord{ID: token.FamilyId, UserEmail: token.UserEmail, RevokedAt: &now}).Error
+}
+
+func (r *PostgresRefreshTokenRepository) PurgeExpired(ctx context.Context, now time.Time) error {
+	return transaction.DB(ctx, r.DB).Where("expires_at < ?", now).Delete(&usedRefreshTokenRecord{}).Error
+}
@@ -0,0 +1,67 @@
+package user_infrastructure
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"gorm.io/gorm"
+)
+
+type rolePermissionRecord struct {
+	Role       string `gorm:"type:varchar(20);primaryKey"`
+	Permission string `gorm:"type:varchar(100);primaryKey"`
+}
+
+func (rolePermissionRecord) TableName() string {
+	return "role_permissions"
+}
+
+// PostgresRolePermissionRepository is a Postgres implementation of RolePermissionRepository using Gorm,
+// permissions changed in the table apply to the next request.
+type PostgresRolePermissionRepository struct {
+	DB *gorm.DB
+}
+
+// NewPostgresRolePermissionRepository seeds the table with defaults when it is empty.
+func NewPostgresRolePermissionRepository(
+	db *gorm.DB,
+	defaults map[user_domain.Role][]user_domain.Permission,
+) (*PostgresRolePermissionRepository, error) {
+	if err := db.AutoMigrate(&rolePermissionRecord{}); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := db.Model(&rolePermissionRecord{}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		var records []rolePermissionRecord
+		for role, permissions := range defaults {
+			for _, permission := range permissions {
+				records = append(records, rolePermissionRecord{Role: string(role), Permission: string(permission)})
+			}
+		}
+		if len(records) > 0 {
+			if err := db.Create(&records).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &PostgresRolePermissionRepository{DB: db}, nil
+}
+
+func (r *PostgresRolePermissionRepository) PermissionsOf(ctx context.Context, role user_domain.Role) ([]user_domain.Permission, error) {
+	var records []rolePermissionRecord
+	if err := transaction.DB(ctx, r.DB).Where("role = ?", string(role)).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	permissions := make([]user_domain.Permission, len(records))
+	for i, record := range records {
+		permissions[i] = user_domain.Permission(record.Permission)
+	}
+
+	return permissions, nil
+}
@@ -6,52 +6,66 @@ import (
 	"fmt"
 	"github.com/jackc/pgconn"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"gorm.io/driver/postgres"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/event"
+	"github.com/mik3lon/starter-template/pkg/transaction"
 	"gorm.io/gorm"
 )
 
 // PostgresUserRepository is a Postgres implementation of UserRepository using Gorm.
+// The domain events recorded by a user are written to the outbox in the same transaction, which
+// is the one of the unit of work running in the context if any.
 type PostgresUserRepository struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	outbox *event.PostgresOutbox
 }
 
-// NewPostgresUserRepository initializes a new Postgres user repository.
-func NewPostgresUserRepository(dsn string) (*PostgresUserRepository, error) {
-	db, err := gorm.Open(postgres.New(postgres.Config{
-		DriverName: "pgx",
-		DSN:        dsn,
-	}), &gorm.Config{})
-	if err != nil {
+// NewPostgresUserRepository initializes a new Postgres user repository sharing db with the
+// transaction manager.
+func NewPostgresUserRepository(db *gorm.DB, outbox *event.PostgresOutbox) (*PostgresUserRepository, error) {
+	// Ensure the User table exists
+	if err := db.AutoMigrate(&user_domain.User{}); err != nil {
 		return nil, err
 	}
 
-	// Ensure the User table exists
-	if err = db.AutoMigrate(&user_domain.User{}); err != nil {
+	// Users were created with ROLE_USER or ROL_USER before roles were normalized.
+	if err := db.Model(&user_domain.User{}).Where("role IN ?", []string{"ROLE_USER", "ROL_USER"}).Update("role", user_domain.RoleUser).Error; err != nil {
 		return nil, err
 	}
 
 	return &PostgresUserRepository{
-		DB: db,
+		DB:     db,
+		outbox: outbox,
 	}, nil
 }
 
 func (r *PostgresUserRepository) Save(ctx context.Context, user *user_domain.User) error {
-	result := r.DB.WithContext(ctx).Save(user)
-	if result.Error != nil {
+	err := r.db(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+
+		var events []bus.Dto
+		for _, e := range user.PullDomainEvents() {
+			events = append(events, e)
+		}
+
+		return r.outbox.Append(tx, events...)
+	})
+	if err != nil {
 		// Check if the error is a unique constraint violation
 		var pgErr *pgconn.PgError
-		errors.As(result.Error, &pgErr)
-		if pgErr.Code == "23505" {
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			return user_domain.NewUserAlreadyExists(user.Email)
 		}
-		return fmt.Errorf("failed to save user: %w", result.Error)
+		return fmt.Errorf("failed to save user: %w", err)
 	}
 	return nil
 }
 
 func (r *PostgresUserRepository) FindByEmail(ctx context.Context, email string) (*user_domain.User, error) {
 	var user *user_domain.User
-	result := r.DB.First(&user, "email = ?", email)
+	result := r.db(ctx).First(&user, "email = ?", email)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, user_domain.NewUserNotFound(email)
@@ -63,7 +77,7 @@ func (r *PostgresUserRepository) FindAll(ctx context.Context, page int, size int
 	var users []*user_domain.User
 	offset := (page - 1) * size
 
-	result := r.DB.Limit(size).Offset(offset).Find(&users)
+	result := r.db(ctx).Limit(size).Offset(offset).Find(&users)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -74,3 +88,7 @@ func (r *PostgresUserRepository) FindAll(ctx context.Context, page int, size int
 	}
 	return userList, nil
 }
+
+func (r *PostgresUserRepository) db(ctx context.Context) *gorm.DB {
+	return transaction.DB(ctx, r.DB)
+}
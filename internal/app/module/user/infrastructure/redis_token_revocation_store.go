@@ -0,0 +1,81 @@
+package user_infrastructure
+
+import (
+	"context"
+	"errors"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// advanceWatermark sets KEYS[1] to ARGV[1] for ARGV[2] milliseconds unless it already holds a later
+// watermark, so concurrent sign outs never move it back.
+var advanceWatermark = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]))
+if current == nil or current < tonumber(ARGV[1]) then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+end
+return 0
+`)
+
+// RedisTokenRevocationStore is a TokenRevocationStore keeping revoked tokens and per user watermarks as
+// expiring Redis keys.
+type RedisTokenRevocationStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisTokenRevocationStore(client *redis.Client, prefix string) *RedisTokenRevocationStore {
+	return &RedisTokenRevocationStore{client: client, prefix: prefix}
+}
+
+func (s *RedisTokenRevocationStore) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.client.Set(ctx, s.tokenKey(tokenId), "1", ttl).Err()
+}
+
+// RevokeIssuedBefore keeps the watermark as long as a token issued right before it may live, it only
+// ever moves forward.
+func (s *RedisTokenRevocationStore) RevokeIssuedBefore(ctx context.Context, email string, before time.Time) error {
+	return advanceWatermark.Run(
+		ctx,
+		s.client,
+		[]string{s.userKey(email)},
+		before.UnixMicro(),
+		user_domain.RefreshTokenTTL.Milliseconds(),
+	).Err()
+}
+
+func (s *RedisTokenRevocationStore) IsRevoked(ctx context.Context, token *user_domain.IssuedToken) (bool, error) {
+	values, err := s.client.MGet(ctx, s.tokenKey(token.ID), s.userKey(token.UserEmail)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+	if values[0] != nil {
+		return true, nil
+	}
+
+	before, ok := values[1].(string)
+	if !ok {
+		return false, nil
+	}
+	watermark, err := strconv.ParseInt(before, 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	return token.IssuedAt.UnixMicro() <= watermark, nil
+}
+
+func (s *RedisTokenRevocationStore) tokenKey(tokenId string) string {
+	return s.prefix + "token:" + tokenId
+}
+
+func (s *RedisTokenRevocationStore) userKey(email string) string {
+	return s.prefix + "user:" + email
+}
@@ -0,0 +1,72 @@
+package user_infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	user_infrastructure "github.com/mik3lon/starter-template/internal/app/module/user/infrastructure"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issuedTokenAt(t *testing.T, issuedAt time.Time) *user_domain.IssuedToken {
+	token, err := user_domain.NewIssuedTokenFromClaims(jwt.MapClaims{
+		"sub": "johndoe@example.com",
+		"jti": issuedAt.String(),
+		"iat": user_domain.IssuedAtClaim(issuedAt),
+		"exp": float64(issuedAt.Add(time.Hour).Unix()),
+	})
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestTokenRevocationStore_RevokeIssuedBefore_KeepsTokensIssuedLaterInTheSameSecond(t *testing.T) {
+	server := miniredis.RunT(t)
+	stores := map[string]user_domain.TokenRevocationStore{
+		"in memory": user_infrastructure.NewInMemoryTokenRevocationStore(),
+		"redis":     user_infrastructure.NewRedisTokenRevocationStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "revocations:"),
+	}
+	watermark := time.Date(2026, 1, 1, 12, 0, 0, 300_000_000, time.UTC)
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.RevokeIssuedBefore(ctx, "johndoe@example.com", watermark))
+
+			revoked, err := store.IsRevoked(ctx, issuedTokenAt(t, watermark.Add(-200*time.Millisecond)))
+			require.NoError(t, err)
+			assert.True(t, revoked)
+
+			revoked, err = store.IsRevoked(ctx, issuedTokenAt(t, watermark.Add(400*time.Millisecond)))
+			require.NoError(t, err)
+			assert.False(t, revoked)
+		})
+	}
+}
+
+func TestTokenRevocationStore_RevokeIssuedBefore_NeverMovesTheWatermarkBack(t *testing.T) {
+	server := miniredis.RunT(t)
+	stores := map[string]user_domain.TokenRevocationStore{
+		"in memory": user_infrastructure.NewInMemoryTokenRevocationStore(),
+		"redis":     user_infrastructure.NewRedisTokenRevocationStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "revocations:"),
+	}
+	watermark := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, store.RevokeIssuedBefore(ctx, "johndoe@example.com", watermark))
+			require.NoError(t, store.RevokeIssuedBefore(ctx, "johndoe@example.com", watermark.Add(-time.Minute)))
+
+			revoked, err := store.IsRevoked(ctx, issuedTokenAt(t, watermark.Add(-time.Second)))
+			require.NoError(t, err)
+			assert.True(t, revoked)
+		})
+	}
+}
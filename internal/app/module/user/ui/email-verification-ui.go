@@ -0,0 +1,73 @@
+package user_ui
+
+import (
+	"github.com/gin-gonic/gin"
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"net/http"
+)
+
+type EmailVerificationHandler struct {
+	jw *http_response.JsonResponseWriter
+	cb command.Bus
+	// queue is the durable transport the verification emails are requested on.
+	queue command.Bus
+}
+
+func NewEmailVerificationHandler(
+	cb command.Bus,
+	queue command.Bus,
+	jw *http_response.JsonResponseWriter,
+) *EmailVerificationHandler {
+	return &EmailVerificationHandler{cb: cb, queue: queue, jw: jw}
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendEmailVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (evh *EmailVerificationHandler) HandleVerifyEmail(g *gin.Context) {
+	var r VerifyEmailRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := evh.cb.Dispatch(g, &user_application.VerifyEmailCommand{Token: r.Token})
+	switch err.(type) {
+	case nil:
+		evh.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
+	case *user_domain.InvalidVerificationToken:
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *user_domain.EmailAlreadyVerified:
+		g.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		evh.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
+
+// HandleResendEmailVerification answers the same for every email, whether it has an account or not, the
+// account is only looked up once the request is queued.
+func (evh *EmailVerificationHandler) HandleResendEmailVerification(g *gin.Context) {
+	var r ResendEmailVerificationRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := evh.queue.DispatchAsync(g, &user_application.SendEmailVerificationCommand{Email: r.Email})
+	switch err.(type) {
+	case nil:
+		evh.jw.WriteResponse(g.Writer, "", http.StatusAccepted)
+	default:
+		evh.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
@@ -1,12 +1,11 @@
 package user_ui
 
 import (
-	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
 	"github.com/mik3lon/starter-template/pkg/bus/query"
 	"net/http"
 )
@@ -24,21 +23,20 @@ func NewGetUserMeHandler(
 }
 
 func (gss *GetUserMeHandler) HandleGetUserMe(g *gin.Context) {
-	email, exists := g.Get("user_email")
-	if !exists {
-		g.JSON(http.StatusBadRequest, gin.H{"error": errors.New("email not exists").Error()})
+	principal, ok := auth.PrincipalFrom(g)
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
-	userResponse, err := gss.qb.Ask(g, &user_application.FindUserQuery{Email: email.(string)})
+	userResponse, err := query.Ask[*user_application.FindUserQuery, *user_application.FindUserResponse](g, gss.qb, &user_application.FindUserQuery{Email: principal.Email})
 	switch err.(type) {
 	case nil:
 		gss.jw.WriteResponse(g.Writer, userResponse, http.StatusOK)
 	case *user_domain.UserNotFound:
 		gss.jw.WriteErrorResponse(g.Writer, err, http.StatusNotFound, err)
 	default:
-		fmt.Printf("error %v", err)
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		gss.jw.WriteBusErrorResponse(g.Writer, err)
 	}
 
 	return
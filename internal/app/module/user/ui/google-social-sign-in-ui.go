@@ -1,9 +1,9 @@
 package user_ui
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
 	"github.com/mik3lon/starter-template/pkg/bus/query"
 	"net/http"
@@ -33,13 +33,14 @@ func (gss *GoogleSocialSignInHandler) HandleGoogleSocialSignIn(g *gin.Context) {
 		return
 	}
 
-	userToken, err := gss.qb.Ask(g, &user_application.GoogleSignInQuery{IdToken: r.IdToken})
+	userToken, err := query.Ask[*user_application.GoogleSignInQuery, *user_domain.TokenDetails](g, gss.qb, &user_application.GoogleSignInQuery{IdToken: r.IdToken})
 	switch err.(type) {
 	case nil:
 		gss.jw.WriteResponse(g.Writer, userToken, http.StatusOK)
+	case *user_domain.EmailNotVerified:
+		g.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 	default:
-		fmt.Printf("error %v", err)
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		gss.jw.WriteBusErrorResponse(g.Writer, err)
 	}
 
 	return
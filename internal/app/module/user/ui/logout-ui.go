@@ -0,0 +1,77 @@
+package user_ui
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"io"
+	"net/http"
+)
+
+type LogoutHandler struct {
+	jw *http_response.JsonResponseWriter
+	cb command.Bus
+}
+
+func NewLogoutHandler(
+	cb command.Bus,
+	jw *http_response.JsonResponseWriter,
+) *LogoutHandler {
+	return &LogoutHandler{cb: cb, jw: jw}
+}
+
+// LogoutRequest may carry the refresh token of the session, so it can not be used to sign in again.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (lh *LogoutHandler) HandleLogout(g *gin.Context) {
+	principal, ok := auth.PrincipalFrom(g)
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var r LogoutRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil && !errors.Is(err, io.EOF) {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := lh.cb.Dispatch(g, &user_application.LogoutCommand{
+		Email:        principal.Email,
+		TokenId:      principal.TokenId,
+		ExpiresAt:    principal.TokenExpiresAt,
+		RefreshToken: r.RefreshToken,
+	})
+	lh.writeResponse(g, err)
+}
+
+func (lh *LogoutHandler) HandleLogoutAll(g *gin.Context) {
+	principal, ok := auth.PrincipalFrom(g)
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	err := lh.cb.Dispatch(g, &user_application.LogoutAllCommand{
+		Email: principal.Email,
+	})
+	lh.writeResponse(g, err)
+}
+
+func (lh *LogoutHandler) writeResponse(g *gin.Context, err error) {
+	switch err.(type) {
+	case nil:
+		lh.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
+	case *user_domain.InvalidRefreshToken:
+		g.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		lh.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
@@ -0,0 +1,110 @@
+package user_ui
+
+import (
+	"github.com/gin-gonic/gin"
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"net/http"
+)
+
+type PasswordHandler struct {
+	jw *http_response.JsonResponseWriter
+	cb command.Bus
+	// queue is the durable transport the password resets are requested on.
+	queue command.Bus
+}
+
+func NewPasswordHandler(
+	cb command.Bus,
+	queue command.Bus,
+	jw *http_response.JsonResponseWriter,
+) *PasswordHandler {
+	return &PasswordHandler{cb: cb, queue: queue, jw: jw}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// HandleForgotPassword answers the same for every email, whether it has an account or not, the account is
+// only looked up once the request is queued.
+func (ph *PasswordHandler) HandleForgotPassword(g *gin.Context) {
+	var r ForgotPasswordRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := ph.queue.DispatchAsync(g, &user_application.RequestPasswordResetCommand{Email: r.Email})
+	switch err.(type) {
+	case nil:
+		ph.jw.WriteResponse(g.Writer, "", http.StatusAccepted)
+	default:
+		ph.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
+
+func (ph *PasswordHandler) HandleResetPassword(g *gin.Context) {
+	var r ResetPasswordRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := ph.cb.Dispatch(g, &user_application.ResetPasswordCommand{
+		Token:       r.Token,
+		NewPassword: r.NewPassword,
+	})
+	switch err.(type) {
+	case nil:
+		ph.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
+	case *user_domain.InvalidPasswordResetToken:
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ph.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
+
+func (ph *PasswordHandler) HandleChangePassword(g *gin.Context) {
+	principal, ok := auth.PrincipalFrom(g)
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var r ChangePasswordRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := ph.cb.Dispatch(g, &user_application.ChangePasswordCommand{
+		Email:           principal.Email,
+		CurrentPassword: r.CurrentPassword,
+		NewPassword:     r.NewPassword,
+	})
+	switch err.(type) {
+	case nil:
+		ph.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
+	case *user_domain.WrongPassword:
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ph.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
@@ -0,0 +1,47 @@
+package user_ui
+
+import (
+	"github.com/gin-gonic/gin"
+	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"net/http"
+)
+
+type RefreshTokenHandler struct {
+	jw *http_response.JsonResponseWriter
+	cb command.Bus
+}
+
+func NewRefreshTokenHandler(
+	cb command.Bus,
+	jw *http_response.JsonResponseWriter,
+) *RefreshTokenHandler {
+	return &RefreshTokenHandler{cb: cb, jw: jw}
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (rth *RefreshTokenHandler) HandleRefreshToken(g *gin.Context) {
+	var r RefreshTokenRequest
+
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c := &user_application.RefreshTokenCommand{RefreshToken: r.RefreshToken}
+	err := rth.cb.Dispatch(g, c)
+
+	switch err.(type) {
+	case nil:
+		rth.jw.WriteResponse(g.Writer, c.Tokens, http.StatusOK)
+	case *user_domain.InvalidRefreshToken, *user_domain.RefreshTokenReused:
+		g.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		rth.jw.WriteBusErrorResponse(g.Writer, err)
+	}
+}
@@ -1,10 +1,10 @@
 package user_ui
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
 	"github.com/mik3lon/starter-template/pkg/bus/command"
 	file2 "github.com/mik3lon/starter-template/pkg/file"
 	"io"
@@ -24,10 +24,9 @@ func NewUpdateUserProfilePhoto(
 }
 
 func (uup *UpdateUserProfilePhoto) HandleUpdateProfilePhoto(g *gin.Context) {
-	// Retrieve email from context
-	email, exists := g.Get("user_email")
-	if !exists {
-		uup.jw.WriteErrorResponse(g.Writer, errors.New("email not exists"), http.StatusBadRequest, nil)
+	principal, ok := auth.PrincipalFrom(g)
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
@@ -51,7 +50,7 @@ func (uup *UpdateUserProfilePhoto) HandleUpdateProfilePhoto(g *gin.Context) {
 	}
 
 	err = uup.cb.Dispatch(g, &user_application.UpdateUserProfilePhotoCommand{
-		Email: email.(string),
+		Email: principal.Email,
 		Image: file2.NewFileInfo(
 			file.Filename,
 			file.Header.Get("Content-Type"),
@@ -60,10 +59,14 @@ func (uup *UpdateUserProfilePhoto) HandleUpdateProfilePhoto(g *gin.Context) {
 		),
 	})
 
-	if err != nil {
-		uup.jw.WriteErrorResponse(g.Writer, err, http.StatusInternalServerError, nil)
-		return
+	switch err.(type) {
+	case nil:
+		uup.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
+	case command.IdempotencyConflict:
+		uup.jw.WriteErrorResponse(g.Writer, err, http.StatusConflict, nil)
+	case command.IdempotencyKeyReused:
+		uup.jw.WriteErrorResponse(g.Writer, err, http.StatusUnprocessableEntity, nil)
+	default:
+		uup.jw.WriteBusErrorResponse(g.Writer, err)
 	}
-
-	uup.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
 }
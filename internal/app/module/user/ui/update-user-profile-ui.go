@@ -1,11 +1,10 @@
 package user_ui
 
 import (
-	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
 	"github.com/mik3lon/starter-template/pkg/bus/command"
 	"net/http"
 )
@@ -29,9 +28,9 @@ type UpdateProfileRequest struct {
 }
 
 func (uup *UpdateUserProfile) HandleUpdateUserProfile(g *gin.Context) {
-	email, exists := g.Get("user_email")
-	if !exists {
-		g.JSON(http.StatusBadRequest, gin.H{"error": errors.New("email not exists").Error()})
+	principal, ok := auth.PrincipalFrom(g)
+	if !ok {
+		g.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
 		return
 	}
 
@@ -43,7 +42,7 @@ func (uup *UpdateUserProfile) HandleUpdateUserProfile(g *gin.Context) {
 	}
 
 	err := uup.cb.Dispatch(g, &user_application.UpdateUserProfileCommand{
-		Email:    email.(string),
+		Email:    principal.Email,
 		Username: r.Username,
 		Name:     r.Name,
 		Surname:  r.Surname,
@@ -52,8 +51,7 @@ func (uup *UpdateUserProfile) HandleUpdateUserProfile(g *gin.Context) {
 	case nil:
 		uup.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
 	default:
-		fmt.Printf("error %v", err)
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		uup.jw.WriteBusErrorResponse(g.Writer, err)
 	}
 
 	return
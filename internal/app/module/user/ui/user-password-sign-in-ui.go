@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
 	"github.com/mik3lon/starter-template/pkg/bus/query"
 	"net/http"
@@ -30,19 +31,17 @@ func (gss *UserPasswordSignInHandler) HandleUserPasswordSignIn(g *gin.Context) {
 		return
 	}
 
-	userToken, err := gss.qb.Ask(g, &user_application.UserPasswordSignInQuery{
+	userToken, err := query.Ask[*user_application.UserPasswordSignInQuery, *user_domain.TokenDetails](g, gss.qb, &user_application.UserPasswordSignInQuery{
 		Email:    email,
 		Password: password,
 	})
-	if err != nil {
-		return
-	}
-
 	switch err.(type) {
 	case nil:
 		gss.jw.WriteResponse(g.Writer, userToken, http.StatusOK)
+	case *user_domain.EmailNotVerified:
+		g.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 	default:
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		gss.jw.WriteBusErrorResponse(g.Writer, err)
 	}
 
 	return
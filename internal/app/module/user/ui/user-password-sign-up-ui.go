@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
 	"github.com/mik3lon/starter-template/pkg/bus/command"
 	"net/http"
@@ -43,7 +44,7 @@ func (gss *UserPasswordSignUpHandler) HandleUserPasswordSignUp(g *gin.Context) {
 			Username:          r.Name,
 			PlainPassword:     r.Password,
 			Email:             r.Email,
-			Role:              "ROLE_USER",
+			Role:              string(user_domain.RoleUser),
 			ProfilePictureUrl: "",
 			IsFormSocialAuth:  false,
 		},
@@ -52,8 +53,12 @@ func (gss *UserPasswordSignUpHandler) HandleUserPasswordSignUp(g *gin.Context) {
 	switch err.(type) {
 	case nil:
 		gss.jw.WriteResponse(g.Writer, "", http.StatusNoContent)
+	case command.IdempotencyConflict:
+		g.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case command.IdempotencyKeyReused:
+		g.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 	default:
-		g.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		gss.jw.WriteBusErrorResponse(g.Writer, err)
 	}
 
 	return
@@ -1,15 +1,20 @@
 package http_response
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
 	"net/http"
 )
 
 type JsonResponseWriter struct {
+	l shared_image_infrastructure.Logger
 }
 
-func NewJsonResponseWriter() *JsonResponseWriter {
-	return &JsonResponseWriter{}
+func NewJsonResponseWriter(l shared_image_infrastructure.Logger) *JsonResponseWriter {
+	return &JsonResponseWriter{l: l}
 }
 
 func (jrw *JsonResponseWriter) WriteErrorResponse(w http.ResponseWriter, err error, httpStatus int, previousError error) {
@@ -17,8 +22,10 @@ func (jrw *JsonResponseWriter) WriteErrorResponse(w http.ResponseWriter, err err
 	w.WriteHeader(httpStatus)
 
 	response := map[string]interface{}{
-		"error":         err,
-		"previousError": previousError.Error(),
+		"error": err,
+	}
+	if previousError != nil {
+		response["previousError"] = previousError.Error()
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -26,6 +33,52 @@ func (jrw *JsonResponseWriter) WriteErrorResponse(w http.ResponseWriter, err err
 	}
 }
 
+// WriteValidationErrorResponse renders err as 422 listing every invalid field.
+func (jrw *JsonResponseWriter) WriteValidationErrorResponse(w http.ResponseWriter, err bus.ValidationError) {
+	jrw.WriteResponse(w, map[string]interface{}{
+		"error":  err.Error(),
+		"fields": err.Fields,
+	}, http.StatusUnprocessableEntity)
+}
+
+// WriteBusErrorResponse renders an error returned by the buses with the status of its bus.ErrorKind.
+// Only domain, validation and forbidden errors are described to the client, any other error
+// is answered with the status text and logged, so internals never leak.
+func (jrw *JsonResponseWriter) WriteBusErrorResponse(w http.ResponseWriter, err error) {
+	var validationErr bus.ValidationError
+	if errors.As(err, &validationErr) {
+		jrw.WriteValidationErrorResponse(w, validationErr)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	message := err.Error()
+	switch bus.KindOf(err) {
+	case bus.KindDomain, bus.KindValidation:
+		status = http.StatusBadRequest
+	case bus.KindForbidden:
+		status = http.StatusForbidden
+	case bus.KindTransient:
+		status = http.StatusServiceUnavailable
+		if errors.As(err, new(bus.HandlerTimeout)) {
+			status = http.StatusGatewayTimeout
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		jrw.l.Error(context.Background(), "error handling request", map[string]interface{}{
+			"error":  err.Error(),
+			"status": status,
+		})
+		message = http.StatusText(status)
+	}
+
+	jrw.WriteResponse(w, map[string]interface{}{
+		"error": message,
+		"kind":  bus.KindOf(err),
+	}, status)
+}
+
 func (jrw *JsonResponseWriter) WriteResponse(w http.ResponseWriter, payload interface{}, httpStatus int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
@@ -0,0 +1,53 @@
+package http_response_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ruleBroken struct{}
+
+func (r ruleBroken) Error() string {
+	return "rule broken"
+}
+
+func (r ruleBroken) ExtraItems() map[string]interface{} {
+	return nil
+}
+
+func TestJsonResponseWriter_WriteBusErrorResponse(t *testing.T) {
+	tests := map[string]struct {
+		err     error
+		status  int
+		message string
+	}{
+		"domain":    {err: &ruleBroken{}, status: http.StatusBadRequest, message: "rule broken"},
+		"forbidden": {err: auth.NewForbidden(user_domain.PermissionUsersWrite), status: http.StatusForbidden, message: "Missing permission users:write"},
+		"timeout":   {err: bus.NewHandlerTimeout("cmd", 0), status: http.StatusGatewayTimeout, message: "Gateway Timeout"},
+		"unknown":   {err: errors.New("pq: connection refused to 10.0.0.3"), status: http.StatusInternalServerError, message: "Internal Server Error"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			http_response.NewJsonResponseWriter(shared_image_infrastructure.NewZerologAdapter()).WriteBusErrorResponse(w, tt.err)
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.message, body["error"])
+		})
+	}
+}
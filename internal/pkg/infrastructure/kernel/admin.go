@@ -0,0 +1,106 @@
+package kernel
+
+import (
+	"github.com/gin-gonic/gin"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"net/http"
+)
+
+const (
+	AdminBus          = "/admin/bus"
+	AdminOutbox       = "/admin/outbox"
+	AdminOutboxReplay = "/admin/outbox/replay"
+)
+
+// MessageInfo is a bus.HandlerInfo along with the module registering it.
+type MessageInfo struct {
+	bus.HandlerInfo
+	Module string `json:"module"`
+}
+
+type BusIntrospection struct {
+	Commands []MessageInfo `json:"commands"`
+	Queries  []MessageInfo `json:"queries"`
+}
+
+// Introspect lists the commands and queries registered in the buses with their owner module and counters.
+func (k *Kernel) Introspect() *BusIntrospection {
+	return &BusIntrospection{
+		Commands: k.withOwners(k.CommandBus.Handlers()),
+		Queries:  k.withOwners(k.QueryBus.Handlers()),
+	}
+}
+
+func (k *Kernel) withOwners(handlers []bus.HandlerInfo) []MessageInfo {
+	messages := make([]MessageInfo, len(handlers))
+	for i, handler := range handlers {
+		messages[i] = MessageInfo{HandlerInfo: handler, Module: k.owners[handler.Name]}
+	}
+
+	return messages
+}
+
+// RegisterAdminRoutes registers the routes used to operate the application, they require the permission
+// of each route.
+func (k *Kernel) RegisterAdminRoutes() {
+	k.Router.Handle(
+		http.MethodGet,
+		AdminBus,
+		k.handleBusIntrospection,
+		k.AuthMiddleware.Check,
+		k.AuthMiddleware.RequirePermission(user_domain.PermissionBusRead),
+	)
+
+	k.Router.Handle(
+		http.MethodGet,
+		AdminOutbox,
+		k.handleOutboxStats,
+		k.AuthMiddleware.Check,
+		k.AuthMiddleware.RequirePermission(user_domain.PermissionBusRead),
+	)
+
+	k.Router.Handle(
+		http.MethodPost,
+		AdminOutboxReplay,
+		k.handleOutboxReplay,
+		k.AuthMiddleware.Check,
+		k.AuthMiddleware.RequirePermission(user_domain.PermissionBusWrite),
+	)
+}
+
+func (k *Kernel) handleBusIntrospection(g *gin.Context) {
+	k.JsonResponseWriter.WriteResponse(g.Writer, k.Introspect(), http.StatusOK)
+}
+
+func (k *Kernel) handleOutboxStats(g *gin.Context) {
+	stats, err := k.OutboxRelay.Stats(g)
+	if err != nil {
+		k.JsonResponseWriter.WriteBusErrorResponse(g.Writer, err)
+		return
+	}
+
+	k.JsonResponseWriter.WriteResponse(g.Writer, stats, http.StatusOK)
+}
+
+// OutboxReplayRequest selects the outbox messages to relay again by their ids, both ends included.
+type OutboxReplayRequest struct {
+	FromId int64 `json:"from_id" binding:"required,min=1"`
+	ToId   int64 `json:"to_id" binding:"required,gtefield=FromId"`
+}
+
+func (k *Kernel) handleOutboxReplay(g *gin.Context) {
+	var r OutboxReplayRequest
+	if err := g.ShouldBindJSON(&r); err != nil {
+		g.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	replayed, err := k.Outbox.Replay(g, r.FromId, r.ToId)
+	if err != nil {
+		k.JsonResponseWriter.WriteBusErrorResponse(g.Writer, err)
+		return
+	}
+
+	k.JsonResponseWriter.WriteResponse(g.Writer, gin.H{"replayed": replayed}, http.StatusOK)
+}
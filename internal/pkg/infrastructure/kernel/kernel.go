@@ -6,51 +6,180 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	http_response "github.com/mik3lon/starter-template/internal/pkg/infrastructure/http/response"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus"
 	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/event"
 	"github.com/mik3lon/starter-template/pkg/bus/query"
+	"github.com/mik3lon/starter-template/pkg/bus/saga"
+	"github.com/mik3lon/starter-template/pkg/bus/scheduler"
+	"github.com/mik3lon/starter-template/pkg/cache"
 	"github.com/mik3lon/starter-template/pkg/config"
 	"github.com/mik3lon/starter-template/pkg/file"
 	"github.com/mik3lon/starter-template/pkg/http/middleware"
 	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/mik3lon/starter-template/pkg/mail"
 	"github.com/mik3lon/starter-template/pkg/router"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 	"net/http"
+	"time"
 )
 
 type Kernel struct {
 	Router             *router.GinRouter
 	Modules            map[string]Module
+	Logger             shared_image_infrastructure.Logger
 	server             *http.Server
 	CommandBus         *command.CommandBus
+	CommandQueue       *command.PostgresCommandQueue
+	Outbox             *event.PostgresOutbox
+	OutboxRelay        *event.OutboxRelay
+	EventConsumer      *shared_image_infrastructure.SQSEventConsumer
 	QueryBus           *query.QueryBus
+	QueryCache         *query.QueryCache
+	EventBus           *event.EventBus
+	Sagas              *saga.Manager
+	Scheduler          *scheduler.Scheduler
 	JsonResponseWriter *http_response.JsonResponseWriter
+	DB                 *gorm.DB
+	TransactionManager transaction.Manager
 
 	AuthMiddleware *middleware.AuthMiddleware
 	ImageUploader  file.ImageUploader
+	KeyRing        *auth.KeyRing
+	Mailer         mail.Mailer
+	// MailTemplates holds the mail templates added by every module.
+	MailTemplates *mail.Templates
+
+	stopWorkers context.CancelFunc
+	// owners maps the name of every command and query to the module registering it.
+	owners map[string]string
 }
 
 // Init initializes the container with a router implementation.
 func Init(cnf *config.Config) *Kernel {
+	// Never taken from the requests, whose Host header anyone can forge.
+	if cnf.JwtIssuer == "" {
+		panic(fmt.Errorf("JWT_ISSUER is required"))
+	}
+
 	r := router.NewGinRouter()
 
 	l := shared_image_infrastructure.NewZerologAdapter()
 
+	db, err := buildDatabase(cnf)
+	if err != nil {
+		panic(err)
+	}
+
 	k := &Kernel{
 		Router: r,
+		Logger: l,
 		server: &http.Server{
 			Addr:    cnf.AddressPort,
 			Handler: r.Handler(),
 		},
 		CommandBus:         command.InitCommandBus(l),
 		QueryBus:           query.InitQueryBus(l),
-		JsonResponseWriter: http_response.NewJsonResponseWriter(),
+		EventBus:           event.InitEventBus(l),
+		JsonResponseWriter: http_response.NewJsonResponseWriter(l),
+		DB:                 db,
+		TransactionManager: transaction.NewGormTransactionManager(db),
 		ImageUploader:      buildImageUploader(buildS3Client(cnf), cnf, l),
+		Mailer:             buildMailer(cnf),
+	}
+
+	k.KeyRing, err = buildKeyRing(cnf)
+	if err != nil {
+		panic(err)
+	}
+
+	k.MailTemplates, err = mail.NewTemplates(cnf.MailDefaultLocale)
+	if err != nil {
+		panic(err)
+	}
+
+	deadLetterStore, err := command.NewPostgresDeadLetterStore(db)
+	if err != nil {
+		panic(err)
+	}
+	k.CommandBus.SetDeadLetterStore(deadLetterStore)
+	k.CommandBus.SetDefaultTimeout(cnf.CommandTimeout)
+	k.QueryBus.SetDefaultTimeout(cnf.QueryTimeout)
+
+	idempotencyStore, err := buildIdempotencyStore(cnf, db)
+	if err != nil {
+		panic(err)
+	}
+	k.CommandBus.Use(bus.ValidationMiddlewareName, command.ValidationMiddleware())
+	k.QueryBus.Use(bus.ValidationMiddlewareName, query.ValidationMiddleware())
+	// Before idempotency and the cache, so principals are never answered with the outcome of a command
+	// or a response cached for another one.
+	k.CommandBus.Use(auth.AuthorizationMiddlewareName, auth.CommandAuthorizationMiddleware())
+	k.QueryBus.Use(auth.AuthorizationMiddlewareName, auth.QueryAuthorizationMiddleware())
+	// Before the rest, so duplicates skip them.
+	k.CommandBus.Use(command.IdempotencyMiddlewareName, command.IdempotencyMiddleware(
+		idempotencyStore,
+		cnf.IdempotencyTTL,
+		cnf.IdempotencyClaimTTL,
+		auth.IdempotencyOwner,
+	))
+
+	k.QueryCache = query.NewQueryCache(buildCache(cnf), l)
+	k.QueryBus.Use(query.CacheMiddlewareName, k.QueryCache.Middleware())
+	k.CommandBus.Use(query.CacheMiddlewareName, k.QueryCache.InvalidationMiddleware())
+	// Innermost, so the outer middlewares only see committed changes.
+	k.CommandBus.Use(command.TransactionMiddlewareName, command.TransactionMiddleware(k.TransactionManager))
+
+	k.CommandQueue, err = command.NewPostgresCommandQueue(db, k.CommandBus, l, cnf.CommandQueueWorkers, cnf.CommandQueuePollInterval)
+	if err != nil {
+		panic(err)
+	}
+	k.CommandBus.SetScheduler(k.CommandQueue)
+	k.Scheduler = scheduler.NewScheduler(k.CommandQueue, scheduler.NewPostgresAdvisoryLock(db, "scheduler"), l, cnf.SchedulerPollInterval)
+
+	k.Outbox, err = event.NewPostgresOutbox(db)
+	if err != nil {
+		panic(err)
+	}
+	// Without a topic the relay hands events straight to the event bus, otherwise they go through
+	// SNS and come back to the event bus from the SQS queue, like events of any other service.
+	var publisher event.Publisher = k.EventBus
+	if cnf.EventsTopic != "" {
+		publisher, k.EventConsumer, err = buildEventTransport(cnf, k.EventBus, l)
+		if err != nil {
+			panic(err)
+		}
 	}
+	k.OutboxRelay = event.NewOutboxRelay(k.Outbox, k.EventBus.Registry(), publisher, l, cnf.OutboxBatchSize, cnf.OutboxPollInterval, command.RetryPolicy{
+		MaxAttempts:    cnf.OutboxMaxAttempts,
+		InitialBackoff: cnf.OutboxPollInterval,
+		MaxBackoff:     5 * time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+	})
+
+	sagaStore, err := saga.NewPostgresStore(db)
+	if err != nil {
+		panic(err)
+	}
+	k.Sagas = saga.NewManager(sagaStore, k.CommandBus, l, cnf.SagaStepTimeout, cnf.SagaPollInterval)
+
+	k.addModule(InitMailModule(k, cnf))
 
 	userModule := InitUserModule(k, cnf)
 	k.addModule(userModule)
+	k.AuthMiddleware = userModule.AuthMiddleware
 
 	k.RegisterModuleRoutes()
+	k.RegisterAdminRoutes()
+	k.RegisterWellKnownRoutes(cnf.JwtIssuer)
 
 	return k
 }
@@ -62,6 +191,21 @@ func (k *Kernel) RegisterModuleRoutes() {
 	}
 }
 
+// StartWorkers starts the background workers owned by the kernel, they stop once ctx is done
+// or the server is shut down. They act as the system, no principal is behind their messages.
+func (k *Kernel) StartWorkers(ctx context.Context) {
+	ctx, k.stopWorkers = context.WithCancel(auth.AsSystem(ctx))
+
+	go k.CommandBus.ProcessFailed(ctx)
+	go k.OutboxRelay.Run(ctx)
+	go k.Sagas.Run(ctx)
+	go k.Scheduler.Run(ctx)
+	if k.EventConsumer != nil {
+		go k.EventConsumer.Run(ctx)
+	}
+	k.CommandQueue.Start(ctx)
+}
+
 // StartServer starts the HTTP server.
 func (k *Kernel) StartServer() error {
 	return k.server.ListenAndServe()
@@ -70,6 +214,7 @@ func (k *Kernel) StartServer() error {
 func (k *Kernel) addModule(module Module) {
 	if k.Modules == nil {
 		k.Modules = make(map[string]Module)
+		k.owners = make(map[string]string)
 	}
 
 	if k.Modules[module.Name()] != nil {
@@ -82,6 +227,7 @@ func (k *Kernel) addModule(module Module) {
 		if err != nil {
 			panic(err)
 		}
+		k.owners[bus.NameOf(c)] = module.Name()
 	}
 
 	for q, ch := range module.Queries() {
@@ -89,11 +235,88 @@ func (k *Kernel) addModule(module Module) {
 		if err != nil {
 			panic(err)
 		}
+		k.owners[bus.NameOf(q)] = module.Name()
+	}
+
+	err := k.EventBus.RegisterEvent(module.Events()...)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, job := range module.Schedules() {
+		if err := k.Scheduler.Register(job); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, fsys := range module.MailTemplates() {
+		if err := k.MailTemplates.Add(fsys); err != nil {
+			panic(fmt.Errorf("module %s: %v", module.Name(), err))
+		}
+	}
+
+	for e, handlers := range module.Subscribers() {
+		for _, eh := range handlers {
+			err := k.EventBus.Subscribe(e, eh)
+			if err != nil {
+				panic(err)
+			}
+		}
 	}
 }
 
+// ShutdownServer stops accepting requests, then stops the workers and waits for their in-flight work.
 func (k *Kernel) ShutdownServer(ctx context.Context) error {
-	return k.server.Shutdown(ctx)
+	if err := k.server.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	if k.stopWorkers == nil {
+		return nil
+	}
+	k.stopWorkers()
+
+	return k.CommandQueue.Wait(ctx)
+}
+
+func buildDatabase(cnf *config.Config) (*gorm.DB, error) {
+	return gorm.Open(postgres.New(postgres.Config{
+		DriverName: "pgx",
+		DSN:        cnf.DatabaseDSN,
+	}), &gorm.Config{})
+}
+
+func buildKeyRing(cnf *config.Config) (*auth.KeyRing, error) {
+	if cnf.JwtKeysDir != "" {
+		return auth.LoadKeyRing(cnf.JwtKeysDir, cnf.JwtActiveKeyId, cnf.PrivateKeyPassword)
+	}
+
+	key, err := auth.ParseKey(cnf.PrivateKeyId, []byte(cnf.PrivateKeyPEM), cnf.PrivateKeyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewKeyRing(key)
+}
+
+func buildCache(cnf *config.Config) cache.Cache {
+	if cnf.CacheBackend == "redis" {
+		return cache.NewRedisCache(buildRedisClient(cnf), "query:")
+	}
+
+	return cache.NewInMemoryLRUCache(cnf.CacheSize)
+}
+
+func buildIdempotencyStore(cnf *config.Config, db *gorm.DB) (command.IdempotencyStore, error) {
+	if cnf.IdempotencyBackend == "redis" {
+		return command.NewRedisIdempotencyStore(buildRedisClient(cnf), "idempotency:"), nil
+	}
+
+	return command.NewPostgresIdempotencyStore(db)
+}
+
+func buildRedisClient(cnf *config.Config) *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: cnf.RedisAddr})
 }
 
 func buildS3Endpoint(cnf *config.Config) string {
@@ -131,3 +354,51 @@ func buildS3Client(config *config.Config) *s3.S3 {
 
 	return s3.New(sess)
 }
+
+func buildEventTransport(
+	cnf *config.Config,
+	eb *event.EventBus,
+	l shared_image_infrastructure.Logger,
+) (*shared_image_infrastructure.SNSEventPublisher, *shared_image_infrastructure.SQSEventConsumer, error) {
+	ctx := context.Background()
+
+	awsConfig := aws.Config{Region: aws.String(cnf.S3Region)}
+	if cnf.EventsEndpoint != "" {
+		awsConfig.Endpoint = aws.String(cnf.EventsEndpoint)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Config:            awsConfig,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	snsClient := sns.New(sess)
+	sqsClient := sqs.New(sess)
+
+	topicArn, err := shared_image_infrastructure.EnsureTopic(ctx, snsClient, cnf.EventsTopic)
+	if err != nil {
+		return nil, nil, err
+	}
+	publisher := shared_image_infrastructure.NewSNSEventPublisher(snsClient, topicArn, l)
+
+	if cnf.EventsQueue == "" {
+		return publisher, nil, nil
+	}
+
+	deadLetterQueue := cnf.EventsDeadLetterQueue
+	if deadLetterQueue == "" {
+		deadLetterQueue = cnf.EventsQueue + "-dlq"
+	}
+	queueUrl, _, err := shared_image_infrastructure.EnsureQueue(ctx, sqsClient, cnf.EventsQueue, deadLetterQueue, cnf.EventsMaxReceiveCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = shared_image_infrastructure.SubscribeQueue(ctx, snsClient, sqsClient, topicArn, queueUrl); err != nil {
+		return nil, nil, err
+	}
+
+	consumer := shared_image_infrastructure.NewSQSEventConsumer(sqsClient, queueUrl, eb.Registry(), eb, l, cnf.EventsVisibilityTimeout)
+
+	return publisher, consumer, nil
+}
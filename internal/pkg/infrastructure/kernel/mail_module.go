@@ -0,0 +1,34 @@
+package kernel
+
+import (
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/config"
+	"github.com/mik3lon/starter-template/pkg/mail"
+)
+
+// MailModule sends the emails of every module through SendEmailCommand, rendered from the templates
+// the modules add with AddMailTemplates.
+type MailModule struct {
+	BaseModule
+}
+
+func (m *MailModule) Name() string {
+	return "mail_module"
+}
+
+func (m *MailModule) RegisterRoutes(c *Kernel) {}
+
+func InitMailModule(k *Kernel, cnf *config.Config) *MailModule {
+	mm := &MailModule{}
+	mm.AddCommand(&mail.SendEmailCommand{}, command.Handler[*mail.SendEmailCommand](mail.NewSendEmailCommandHandler(k.Mailer, k.MailTemplates, cnf.MailFrom)))
+
+	return mm
+}
+
+func buildMailer(cnf *config.Config) mail.Mailer {
+	if cnf.MailerBackend == "memory" {
+		return mail.NewInMemoryMailer()
+	}
+
+	return mail.NewSMTPMailer(cnf.SMTPHost, cnf.SMTPPort, cnf.SMTPUsername, cnf.SMTPPassword)
+}
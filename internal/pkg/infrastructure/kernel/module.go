@@ -3,7 +3,10 @@ package kernel
 import (
 	"github.com/mik3lon/starter-template/pkg/bus"
 	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/event"
 	"github.com/mik3lon/starter-template/pkg/bus/query"
+	"github.com/mik3lon/starter-template/pkg/bus/scheduler"
+	"io/fs"
 )
 
 type Modules []Module
@@ -14,11 +17,19 @@ type Module interface {
 	Name() string
 	Commands() map[bus.Dto]command.CommandHandler
 	Queries() map[bus.Dto]query.QueryHandler
+	Subscribers() map[bus.Dto][]event.EventHandler
+	Events() []bus.Dto
+	Schedules() []scheduler.Job
+	MailTemplates() []fs.FS
 }
 
 type BaseModule struct {
-	commands map[bus.Dto]command.CommandHandler
-	queries  map[bus.Dto]query.QueryHandler
+	commands    map[bus.Dto]command.CommandHandler
+	queries     map[bus.Dto]query.QueryHandler
+	subscribers map[bus.Dto][]event.EventHandler
+	events      []bus.Dto
+	schedules   []scheduler.Job
+	templates   []fs.FS
 }
 
 // AddCommand adds a command to the module
@@ -37,6 +48,29 @@ func (bm *BaseModule) AddQuery(c bus.Dto, queryHandler query.QueryHandler) {
 	bm.queries[c] = queryHandler
 }
 
+// AddSubscriber subscribes an event handler to an event, an event may have many subscribers
+func (bm *BaseModule) AddSubscriber(e bus.Dto, eventHandler event.EventHandler) {
+	if bm.subscribers == nil {
+		bm.subscribers = make(map[bus.Dto][]event.EventHandler)
+	}
+	bm.subscribers[e] = append(bm.subscribers[e], eventHandler)
+}
+
+// AddEvent declares an event recorded by the module, so it can be rebuilt by event transports
+func (bm *BaseModule) AddEvent(e bus.Dto) {
+	bm.events = append(bm.events, e)
+}
+
+// AddSchedule dispatches a command on every occurrence of spec, a cron expression
+func (bm *BaseModule) AddSchedule(name string, spec string, c bus.Dto) {
+	bm.schedules = append(bm.schedules, scheduler.Job{Name: name, Spec: spec, Command: c})
+}
+
+// AddMailTemplates adds the mail templates found at the root of fsys, see mail.Templates
+func (bm *BaseModule) AddMailTemplates(fsys fs.FS) {
+	bm.templates = append(bm.templates, fsys)
+}
+
 // Commands returns all commands registered in the module
 func (bm *BaseModule) Commands() map[bus.Dto]command.CommandHandler {
 	return bm.commands
@@ -46,3 +80,23 @@ func (bm *BaseModule) Commands() map[bus.Dto]command.CommandHandler {
 func (bm *BaseModule) Queries() map[bus.Dto]query.QueryHandler {
 	return bm.queries
 }
+
+// Subscribers returns all event handlers subscribed by the module
+func (bm *BaseModule) Subscribers() map[bus.Dto][]event.EventHandler {
+	return bm.subscribers
+}
+
+// Events returns all events declared by the module
+func (bm *BaseModule) Events() []bus.Dto {
+	return bm.events
+}
+
+// Schedules returns all recurring jobs registered in the module
+func (bm *BaseModule) Schedules() []scheduler.Job {
+	return bm.schedules
+}
+
+// MailTemplates returns all mail templates added by the module
+func (bm *BaseModule) MailTemplates() []fs.FS {
+	return bm.templates
+}
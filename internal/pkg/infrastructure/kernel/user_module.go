@@ -1,6 +1,7 @@
 package kernel
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	_ "github.com/jackc/pgx/v4/stdlib" // Import the pgx driver
 	user_application "github.com/mik3lon/starter-template/internal/app/module/user/application"
@@ -8,9 +9,13 @@ import (
 	user_infrastructure "github.com/mik3lon/starter-template/internal/app/module/user/infrastructure"
 	user_ui "github.com/mik3lon/starter-template/internal/app/module/user/ui"
 	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/query"
 	"github.com/mik3lon/starter-template/pkg/config"
 	"github.com/mik3lon/starter-template/pkg/http/middleware"
 	"net/http"
+	"time"
 )
 
 const (
@@ -27,6 +32,10 @@ type UserModule struct {
 	GoogleSocialSignInHandler *user_ui.GoogleSocialSignInHandler
 	UserPasswordSignInHandler *user_ui.UserPasswordSignInHandler
 	UserPasswordSignUpHandler *user_ui.UserPasswordSignUpHandler
+	RefreshTokenHandler       *user_ui.RefreshTokenHandler
+	LogoutHandler             *user_ui.LogoutHandler
+	EmailVerificationHandler  *user_ui.EmailVerificationHandler
+	PasswordHandler           *user_ui.PasswordHandler
 
 	IdTokenValidator   user_domain.IdTokenValidator
 	GetUserMeHandler   *user_ui.GetUserMeHandler
@@ -43,27 +52,41 @@ func (m *UserModule) Name() string {
 
 // InitUserModule creates a new instance of NotificationModule.
 func InitUserModule(k *Kernel, cnf *config.Config) *UserModule {
-	_, err := user_infrastructure.NewPostgresUserRepository(cnf.DatabaseDSN)
+	r, err := user_infrastructure.NewPostgresUserRepository(k.DB, k.Outbox)
 	if err != nil {
 		panic(err)
 	}
 
-	r, err := user_infrastructure.NewPostgresUserRepository(cnf.DatabaseDSN)
+	rtr, err := user_infrastructure.NewPostgresRefreshTokenRepository(k.DB)
 	if err != nil {
-		panic("error connecting with the database")
+		panic(err)
+	}
+
+	prtr, err := user_infrastructure.NewPostgresPasswordResetTokenRepository(k.DB)
+	if err != nil {
+		panic(err)
 	}
 
-	ue := auth.NewJWTUserEncoder(cnf.PrivateKeyPEM, cnf.PrivateKeyPassword, cnf.PublicKeyPEM)
+	rts := buildTokenRevocationStore(k, cnf)
+	rpr, err := buildRolePermissionRepository(k, cnf)
+	if err != nil {
+		panic(err)
+	}
+	ue := auth.NewJWTUserEncoder(k.KeyRing, cnf.JwtIssuer)
 
 	um := &UserModule{
 		UserRepository:            r,
 		UserEncoder:               ue,
-		AuthMiddleware:            middleware.NewAuthMiddleware(r, ue),
+		AuthMiddleware:            middleware.NewAuthMiddleware(r, ue, rts, rpr),
 		UserSignInIndexHandler:    user_ui.HandleUserSocialSignInIndex,
 		GoogleSocialSignInHandler: user_ui.NewGoogleSocialSignInHandler(k.QueryBus, k.JsonResponseWriter),
 		IdTokenValidator:          user_infrastructure.NewGoogleIDTokenValidator(cnf.GoogleClientId),
 		UserPasswordSignInHandler: user_ui.NewUserPasswordSignInHandler(k.QueryBus, k.JsonResponseWriter),
 		UserPasswordSignUpHandler: user_ui.NewUserPasswordSignUpHandler(k.CommandBus, k.JsonResponseWriter),
+		RefreshTokenHandler:       user_ui.NewRefreshTokenHandler(k.CommandBus, k.JsonResponseWriter),
+		LogoutHandler:             user_ui.NewLogoutHandler(k.CommandBus, k.JsonResponseWriter),
+		EmailVerificationHandler:  user_ui.NewEmailVerificationHandler(k.CommandBus, k.CommandQueue, k.JsonResponseWriter),
+		PasswordHandler:           user_ui.NewPasswordHandler(k.CommandBus, k.CommandQueue, k.JsonResponseWriter),
 		GetUserMeHandler:          user_ui.NewGetUserMeHandler(k.QueryBus, k.JsonResponseWriter),
 		UpdateUserProfile:         user_ui.NewUpdateUserProfile(k.CommandBus, k.JsonResponseWriter),
 		UpdateProfilePhoto:        user_ui.NewUpdateUserProfilePhoto(k.CommandBus, k.JsonResponseWriter),
@@ -71,13 +94,47 @@ func InitUserModule(k *Kernel, cnf *config.Config) *UserModule {
 
 	pe := user_infrastructure.NewBcryptPasswordEncrypter()
 
-	um.AddCommand(&user_application.CreateUserCommand{}, user_application.NewCreateUserCommandHandler(r, pe))
-	um.AddCommand(&user_application.UpdateUserProfileCommand{}, user_application.NewUpdateUserProfileCommandHandler(r))
-	um.AddCommand(&user_application.UpdateUserProfilePhotoCommand{}, user_application.NewUpdateUserProfilePhotoCommandHandler(r, k.ImageUploader))
+	um.AddEvent(&user_domain.UserCreated{})
+	um.AddEvent(&user_domain.UserProfileUpdated{})
+	um.AddEvent(&user_domain.UserProfilePhotoChanged{})
+	um.AddEvent(&user_domain.EmailVerificationRequested{})
+	um.AddEvent(&user_domain.UserEmailVerified{})
+	um.AddEvent(&user_domain.PasswordResetRequested{})
+	um.AddEvent(&user_domain.UserPasswordChanged{})
 
-	um.AddQuery(&user_application.GoogleSignInQuery{}, user_application.NewGoogleSignInQueryHandler(r, um.IdTokenValidator, ue, pe))
-	um.AddQuery(&user_application.FindUserQuery{}, user_application.NewFindUserQueryHandler(r))
-	um.AddQuery(&user_application.UserPasswordSignInQuery{}, user_application.NewUserPasswordSignInQueryHandler(r, ue, pe))
+	um.AddMailTemplates(user_infrastructure.MailTemplates())
+
+	um.AddSubscriber(&user_domain.UserCreated{}, user_application.NewSendEmailVerificationOnUserCreated(k.CommandQueue))
+	um.AddSubscriber(&user_domain.UserCreated{}, user_application.NewSendWelcomeEmailOnUserCreated(k.CommandQueue))
+
+	um.AddCommand(&user_application.CreateUserCommand{}, command.Handler[*user_application.CreateUserCommand](user_application.NewCreateUserCommandHandler(r, pe)))
+	um.AddCommand(&user_application.UpdateUserProfileCommand{}, command.Handler[*user_application.UpdateUserProfileCommand](user_application.NewUpdateUserProfileCommandHandler(r)))
+	um.AddCommand(&user_application.UpdateUserProfilePhotoCommand{}, command.Handler[*user_application.UpdateUserProfilePhotoCommand](user_application.NewUpdateUserProfilePhotoCommandHandler(r, k.ImageUploader)))
+	um.AddCommand(&user_application.SendEmailVerificationCommand{}, command.Handler[*user_application.SendEmailVerificationCommand](user_application.NewSendEmailVerificationCommandHandler(r, ue, k.CommandBus, cnf.EmailVerificationTTL, cnf.EmailVerificationUrl)))
+	um.AddCommand(&user_application.VerifyEmailCommand{}, command.Handler[*user_application.VerifyEmailCommand](user_application.NewVerifyEmailCommandHandler(r, ue)))
+	um.AddCommand(&user_application.RequestPasswordResetCommand{}, command.Handler[*user_application.RequestPasswordResetCommand](user_application.NewRequestPasswordResetCommandHandler(r, prtr, k.CommandBus, cnf.PasswordResetTTL, cnf.PasswordResetUrl)))
+	um.AddCommand(&user_application.ResetPasswordCommand{}, command.Handler[*user_application.ResetPasswordCommand](user_application.NewResetPasswordCommandHandler(r, prtr, pe, rts)))
+	um.AddCommand(&user_application.ChangePasswordCommand{}, command.Handler[*user_application.ChangePasswordCommand](user_application.NewChangePasswordCommandHandler(r, pe, rts)))
+	um.AddCommand(&user_application.RefreshTokenCommand{}, command.Handler[*user_application.RefreshTokenCommand](user_application.NewRefreshTokenCommandHandler(r, rtr, rts, ue)))
+	um.AddCommand(&user_application.LogoutCommand{}, command.Handler[*user_application.LogoutCommand](user_application.NewLogoutCommandHandler(rts, rtr, ue)))
+	um.AddCommand(&user_application.LogoutAllCommand{}, command.Handler[*user_application.LogoutAllCommand](user_application.NewLogoutAllCommandHandler(rts)))
+	um.AddCommand(&user_application.PurgeUsedRefreshTokensCommand{}, command.Handler[*user_application.PurgeUsedRefreshTokensCommand](user_application.NewPurgeUsedRefreshTokensCommandHandler(rtr)))
+	um.AddSchedule("purge-used-refresh-tokens", "@hourly", &user_application.PurgeUsedRefreshTokensCommand{})
+	if err := k.CommandBus.SetTimeout(&user_application.UpdateUserProfilePhotoCommand{}, cnf.ImageUploadTimeout); err != nil {
+		panic(err)
+	}
+
+	um.AddQuery(&user_application.GoogleSignInQuery{}, query.Handler[*user_application.GoogleSignInQuery, *user_domain.TokenDetails](user_application.NewGoogleSignInQueryHandler(r, um.IdTokenValidator, ue, pe, rts, cnf.RequireEmailVerification)))
+	um.AddQuery(&user_application.FindUserQuery{}, query.Handler[*user_application.FindUserQuery, *user_application.FindUserResponse](user_application.NewFindUserQueryHandler(r)))
+	um.AddQuery(&user_application.UserPasswordSignInQuery{}, query.Handler[*user_application.UserPasswordSignInQuery, *user_domain.TokenDetails](user_application.NewUserPasswordSignInQueryHandler(r, ue, pe, cnf.RequireEmailVerification)))
+
+	query.CacheQuery[*user_application.FindUserQuery, *user_application.FindUserResponse](k.QueryCache, cnf.QueryCacheTTL)
+	query.EvictOn(k.QueryCache, func(c *user_application.UpdateUserProfileCommand) []bus.Dto {
+		return []bus.Dto{&user_application.FindUserQuery{Email: c.Email}}
+	})
+	query.EvictOn(k.QueryCache, func(c *user_application.UpdateUserProfilePhotoCommand) []bus.Dto {
+		return []bus.Dto{&user_application.FindUserQuery{Email: c.Email}}
+	})
 
 	return um
 }
@@ -101,11 +158,56 @@ func (m *UserModule) RegisterRoutes(c *Kernel) {
 		m.UserPasswordSignUpHandler.HandleUserPasswordSignUp,
 	)
 
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/refresh",
+		m.RefreshTokenHandler.HandleRefreshToken,
+	)
+
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/verify-email",
+		m.EmailVerificationHandler.HandleVerifyEmail,
+	)
+
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/verify-email/resend",
+		m.EmailVerificationHandler.HandleResendEmailVerification,
+	)
+
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/password/forgot",
+		m.PasswordHandler.HandleForgotPassword,
+	)
+
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/password/reset",
+		m.PasswordHandler.HandleResetPassword,
+	)
+
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/logout",
+		m.LogoutHandler.HandleLogout,
+		m.AuthMiddleware.Check,
+	)
+
+	c.Router.Handle(
+		http.MethodPost,
+		"/users/auth/logout-all",
+		m.LogoutHandler.HandleLogoutAll,
+		m.AuthMiddleware.Check,
+	)
+
 	c.Router.Handle(
 		http.MethodGet,
 		GetUserMe,
 		m.GetUserMeHandler.HandleGetUserMe,
 		m.AuthMiddleware.Check,
+		m.AuthMiddleware.RequirePermission(user_domain.PermissionProfileRead),
 	)
 
 	c.Router.Handle(
@@ -113,6 +215,7 @@ func (m *UserModule) RegisterRoutes(c *Kernel) {
 		"/users/me",
 		m.UpdateUserProfile.HandleUpdateUserProfile,
 		m.AuthMiddleware.Check,
+		m.AuthMiddleware.RequirePermission(user_domain.PermissionProfileWrite),
 	)
 
 	c.Router.Handle(
@@ -120,5 +223,49 @@ func (m *UserModule) RegisterRoutes(c *Kernel) {
 		"/users/me/photo",
 		m.UpdateProfilePhoto.HandleUpdateProfilePhoto,
 		m.AuthMiddleware.Check,
+		m.AuthMiddleware.RequirePermission(user_domain.PermissionProfileWrite),
 	)
+
+	c.Router.Handle(
+		http.MethodPut,
+		"/users/me/password",
+		m.PasswordHandler.HandleChangePassword,
+		m.AuthMiddleware.Check,
+		m.AuthMiddleware.RequirePermission(user_domain.PermissionProfileWrite),
+	)
+}
+
+// buildTokenRevocationStore falls back to memory when redis is down, so a local run does not need it, at
+// the cost of revocations not reaching the other instances.
+func buildTokenRevocationStore(k *Kernel, cnf *config.Config) user_domain.TokenRevocationStore {
+	if cnf.TokenRevocationBackend != "redis" {
+		return user_infrastructure.NewInMemoryTokenRevocationStore()
+	}
+
+	client := buildRedisClient(cnf)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		k.Logger.Warn(ctx, "redis unreachable, revoking tokens in memory only", map[string]interface{}{
+			"error":      err.Error(),
+			"redis_addr": cnf.RedisAddr,
+		})
+		_ = client.Close()
+		return user_infrastructure.NewInMemoryTokenRevocationStore()
+	}
+
+	return user_infrastructure.NewRedisTokenRevocationStore(client, "revoked:")
+}
+
+func buildRolePermissionRepository(k *Kernel, cnf *config.Config) (user_domain.RolePermissionRepository, error) {
+	permissions, err := user_infrastructure.ParseRolePermissions(cnf.RolePermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	if cnf.RolePermissionsBackend == "postgres" {
+		return user_infrastructure.NewPostgresRolePermissionRepository(k.DB, permissions)
+	}
+
+	return user_infrastructure.NewConfigRolePermissionRepository(permissions), nil
 }
@@ -0,0 +1,68 @@
+package kernel
+
+import (
+	"github.com/gin-gonic/gin"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"net/http"
+)
+
+const (
+	WellKnownJWKS                = "/.well-known/jwks.json"
+	WellKnownOpenIDConfiguration = "/.well-known/openid-configuration"
+)
+
+// OpenIDConfiguration is the subset of the OpenID Connect discovery document other services need to
+// verify our tokens.
+type OpenIDConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	JwksUri                          string   `json:"jwks_uri"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                  []string `json:"claims_supported"`
+}
+
+// RegisterWellKnownRoutes registers the public routes describing how our tokens are signed by issuer.
+func (k *Kernel) RegisterWellKnownRoutes(issuer string) {
+	k.Router.Handle(
+		http.MethodGet,
+		WellKnownJWKS,
+		k.handleJWKS,
+	)
+
+	k.Router.Handle(
+		http.MethodGet,
+		WellKnownOpenIDConfiguration,
+		func(g *gin.Context) {
+			k.handleOpenIDConfiguration(g, issuer)
+		},
+	)
+}
+
+func (k *Kernel) handleJWKS(g *gin.Context) {
+	jwks, err := k.KeyRing.JWKS()
+	if err != nil {
+		k.JsonResponseWriter.WriteBusErrorResponse(g.Writer, err)
+		return
+	}
+
+	// Verifiers cache the keys, new keys are published before they sign anything.
+	g.Header("Cache-Control", "public, max-age=300")
+	k.JsonResponseWriter.WriteResponse(g.Writer, jwks, http.StatusOK)
+}
+
+func (k *Kernel) handleOpenIDConfiguration(g *gin.Context, issuer string) {
+	k.JsonResponseWriter.WriteResponse(g.Writer, &OpenIDConfiguration{
+		Issuer:                           issuer,
+		JwksUri:                          issuer + WellKnownJWKS,
+		TokenEndpoint:                    issuer + "/users/auth/signin",
+		ResponseTypesSupported:           []string{"token"},
+		SubjectTypesSupported:            []string{"public"},
+		IdTokenSigningAlgValuesSupported: k.KeyRing.Algorithms(),
+		ClaimsSupported: []string{
+			"iss", "sub", "exp", "iat", "jti",
+			user_domain.TokenTypeClaim, user_domain.TokenFamilyClaim,
+		},
+	}, http.StatusOK)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/query"
+)
+
+// AuthorizationMiddlewareName is the name the authorization middlewares are expected to be used with.
+const AuthorizationMiddlewareName = "authorization"
+
+// Owned is a message acting on the resources of a user, sent by that user or by a principal granted
+// OwnerPermission.
+type Owned interface {
+	OwnerEmail() string
+	OwnerPermission() user_domain.Permission
+}
+
+// CommandAuthorizationMiddleware runs Authorize for Owned commands. It must run before any middleware
+// answering without the handler.
+func CommandAuthorizationMiddleware() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(ctx context.Context, c bus.Dto) error {
+			if err := authorizeOwned(ctx, c); err != nil {
+				return err
+			}
+
+			return next(ctx, c)
+		}
+	}
+}
+
+// QueryAuthorizationMiddleware runs Authorize for Owned queries. It must run before the cache, whose
+// responses are shared between principals.
+func QueryAuthorizationMiddleware() query.Middleware {
+	return func(next query.HandlerFunc) query.HandlerFunc {
+		return func(ctx context.Context, q bus.Dto) (interface{}, error) {
+			if err := authorizeOwned(ctx, q); err != nil {
+				return nil, err
+			}
+
+			return next(ctx, q)
+		}
+	}
+}
+
+func authorizeOwned(ctx context.Context, dto bus.Dto) error {
+	owned, ok := dto.(Owned)
+	if !ok {
+		return nil
+	}
+
+	return Authorize(ctx, owned.OwnerEmail(), owned.OwnerPermission())
+}
@@ -0,0 +1,106 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/query"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type findProfileQuery struct {
+	Email string
+}
+
+func (q findProfileQuery) OwnerEmail() string {
+	return q.Email
+}
+
+func (q findProfileQuery) OwnerPermission() user_domain.Permission {
+	return user_domain.PermissionUsersRead
+}
+
+func TestQueryAuthorizationMiddleware_StopsQueriesOfOtherUsers(t *testing.T) {
+	called := false
+	next := func(ctx context.Context, q bus.Dto) (interface{}, error) {
+		called = true
+		return "profile", nil
+	}
+	handle := auth.QueryAuthorizationMiddleware()(query.HandlerFunc(next))
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Email: "janedoe@example.com"})
+
+	_, err := handle(ctx, &findProfileQuery{Email: "johndoe@example.com"})
+
+	assert.IsType(t, auth.Forbidden{}, err)
+	assert.False(t, called)
+
+	response, err := handle(ctx, &findProfileQuery{Email: "janedoe@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "profile", response)
+}
+
+func TestQueryAuthorizationMiddleware_FailsClosedWithoutPrincipal(t *testing.T) {
+	next := func(ctx context.Context, q bus.Dto) (interface{}, error) {
+		return "profile", nil
+	}
+	handle := auth.QueryAuthorizationMiddleware()(query.HandlerFunc(next))
+
+	_, err := handle(context.Background(), &findProfileQuery{Email: "johndoe@example.com"})
+
+	assert.IsType(t, auth.Forbidden{}, err)
+
+	response, err := handle(auth.AsSystem(context.Background()), &findProfileQuery{Email: "johndoe@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "profile", response)
+}
+
+type updateProfileCommand struct {
+	Email string
+}
+
+func (c updateProfileCommand) OwnerEmail() string {
+	return c.Email
+}
+
+func (c updateProfileCommand) OwnerPermission() user_domain.Permission {
+	return user_domain.PermissionUsersWrite
+}
+
+func (c updateProfileCommand) IdempotencyOwner() string {
+	return c.Email
+}
+
+type noopCommandHandler struct{}
+
+func (noopCommandHandler) Handle(ctx context.Context, c bus.Dto) error {
+	return nil
+}
+
+func TestCommandAuthorizationMiddleware_RunsBeforeIdempotentReplays(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use(auth.AuthorizationMiddlewareName, auth.CommandAuthorizationMiddleware())
+	cb.Use(command.IdempotencyMiddlewareName, command.IdempotencyMiddleware(
+		command.NewInMemoryIdempotencyStore(),
+		time.Minute,
+		time.Second,
+		auth.IdempotencyOwner,
+	))
+	require.NoError(t, cb.RegisterCommand(&updateProfileCommand{}, noopCommandHandler{}))
+
+	john := auth.WithPrincipal(command.WithIdempotencyKey(context.Background(), "key"), &auth.Principal{Email: "johndoe@example.com"})
+	jane := auth.WithPrincipal(command.WithIdempotencyKey(context.Background(), "key"), &auth.Principal{Email: "janedoe@example.com"})
+
+	require.NoError(t, cb.Dispatch(john, &updateProfileCommand{Email: "johndoe@example.com"}))
+	err := cb.Dispatch(jane, &updateProfileCommand{Email: "johndoe@example.com"})
+
+	assert.IsType(t, auth.Forbidden{}, err)
+}
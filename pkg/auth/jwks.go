@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JSONWebKey is the public part of a Key as described by RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// ECDSA and Ed25519 keys.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring, the ones other services verify our tokens with.
+func (kr *KeyRing) JWKS() (*JSONWebKeySet, error) {
+	set := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(kr.keys))}
+	for _, key := range kr.Keys() {
+		jwk, err := newJSONWebKey(key.public)
+		if err != nil {
+			return nil, err
+		}
+		jwk.Kid = key.Id
+		jwk.Use = "sig"
+		jwk.Alg = key.Method.Alg()
+		set.Keys = append(set.Keys, *jwk)
+	}
+
+	return set, nil
+}
+
+// Algorithms returns the signing algorithms of the keys of the ring, without duplicates.
+func (kr *KeyRing) Algorithms() []string {
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, key := range kr.Keys() {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algorithms = append(algorithms, alg)
+		}
+	}
+
+	return algorithms
+}
+
+func newJSONWebKey(public crypto.PublicKey) (*JSONWebKey, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return &JSONWebKey{
+			Kty: "RSA",
+			N:   encodeSegment(key.N.Bytes()),
+			E:   encodeSegment(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		// Coordinates are padded to the size of the curve, as RFC 7518 requires.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return &JSONWebKey{
+			Kty: "EC",
+			Crv: key.Curve.Params().Name,
+			X:   encodeSegment(key.X.FillBytes(make([]byte, size))),
+			Y:   encodeSegment(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return &JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: encodeSegment(key)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+// Thumbprint returns the RFC 7638 thumbprint of public, a stable id for keys not given one.
+func Thumbprint(public crypto.PublicKey) (string, error) {
+	jwk, err := newJSONWebKey(public)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the required members take part, marshalled with their names sorted.
+	members := map[string]string{"kty": jwk.Kty}
+	switch jwk.Kty {
+	case "RSA":
+		members["n"], members["e"] = jwk.N, jwk.E
+	case "EC":
+		members["crv"], members["x"], members["y"] = jwk.Crv, jwk.X, jwk.Y
+	case "OKP":
+		members["crv"], members["x"] = jwk.Crv, jwk.X
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+
+	return encodeSegment(sum[:]), nil
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
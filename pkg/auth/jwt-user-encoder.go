@@ -1,93 +1,65 @@
 package auth
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"fmt"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
-	"strings"
 	"time"
 )
 
 type JWTUserEncoder struct {
-	privateKeyPEM      string
-	privateKeyPassword string
-	publicKeyPEM       string
+	keys *KeyRing
+	// issuer is the `iss` claim of the tokens, matching the OpenID configuration, tokens of any other
+	// issuer are rejected.
+	issuer string
 }
 
-func NewJWTUserEncoder(privateKey string, password string, publicKey string) *JWTUserEncoder {
-	return &JWTUserEncoder{
-		privateKeyPEM:      strings.Replace(privateKey, `\n`, "\n", 100),
-		privateKeyPassword: password,
-		publicKeyPEM:       strings.Replace(publicKey, `\n`, "\n", 100),
-	}
+func NewJWTUserEncoder(keys *KeyRing, issuer string) *JWTUserEncoder {
+	return &JWTUserEncoder{keys: keys, issuer: issuer}
 }
 
-// loadPrivateKey loads an RSA private key from a PEM file with an optional privateKeyPassword
-func loadPrivateKey(pemFile, password string) (*rsa.PrivateKey, error) {
-	signBytes := []byte(pemFile)
-
-	if password != "" {
-		return jwt.ParseRSAPrivateKeyFromPEMWithPassword(signBytes, password)
-	}
-
-	return jwt.ParseRSAPrivateKeyFromPEM(signBytes)
+// GenerateToken generates access and refresh tokens, the refresh token starting a new family
+func (jue *JWTUserEncoder) GenerateToken(user *user_domain.User) (*user_domain.TokenDetails, error) {
+	return jue.generateToken(user, uuid.NewString())
 }
 
-// loadPublicKey loads an RSA public key from a PEM file
-func loadPublicKey(pemFile string) (*rsa.PublicKey, error) {
-	block, _ := pem.Decode([]byte(pemFile))
-	if block == nil {
-		return nil, fmt.Errorf("failed to parse PEM block containing the public key")
-	}
-
-	// Parse the public key
-	pubKey, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse public key: %v", err)
-	}
-
-	// Assert the parsed key is an RSA public key
-	rsaPubKey, ok := pubKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("not an RSA public key")
-	}
-
-	return rsaPubKey, nil
+// RotateToken generates access and refresh tokens, the refresh token continuing familyId
+func (jue *JWTUserEncoder) RotateToken(user *user_domain.User, familyId string) (*user_domain.TokenDetails, error) {
+	return jue.generateToken(user, familyId)
 }
 
-// GenerateToken generates access and refresh tokens
-func (jue *JWTUserEncoder) GenerateToken(user *user_domain.User) (*user_domain.TokenDetails, error) {
-	// Load the private key
-	privateKey, err := loadPrivateKey(jue.privateKeyPEM, jue.privateKeyPassword)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load private key: %v", err)
-	}
-
+func (jue *JWTUserEncoder) generateToken(user *user_domain.User, familyId string) (*user_domain.TokenDetails, error) {
 	// Set token expiration times
-	accessTokenExpiration := time.Now().Add(2 * time.Hour).Unix()       // 15 minutes
-	refreshTokenExpiration := time.Now().Add(7 * 24 * time.Hour).Unix() // 7 days
+	now := time.Now()
+	accessTokenExpiration := now.Add(user_domain.AccessTokenTTL).Unix()
+	refreshTokenExpiration := now.Add(user_domain.RefreshTokenTTL).Unix()
 
-	// Create the access token
+	// Create the access token, identified so it can be revoked and carrying the role it is authorized with
 	accessClaims := jwt.MapClaims{
-		"sub": user.Email,
-		"exp": accessTokenExpiration,
+		"sub":                      user.Email,
+		"exp":                      accessTokenExpiration,
+		"iat":                      user_domain.IssuedAtClaim(now),
+		"jti":                      uuid.NewString(),
+		user_domain.TokenTypeClaim: user_domain.AccessTokenType,
+		user_domain.UserIdClaim:    user.ID,
+		user_domain.RoleClaim:      string(user.Role),
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims)
-	signedAccessToken, err := accessToken.SignedString(privateKey)
+	signedAccessToken, err := jue.sign(accessClaims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign access token: %v", err)
 	}
 
-	// Create the refresh token
+	// Create the refresh token, identified so it can only be used once
 	refreshClaims := jwt.MapClaims{
-		"sub": user.Email,
-		"exp": refreshTokenExpiration,
+		"sub":                        user.Email,
+		"exp":                        refreshTokenExpiration,
+		"iat":                        user_domain.IssuedAtClaim(now),
+		"jti":                        uuid.NewString(),
+		user_domain.TokenTypeClaim:   user_domain.RefreshTokenType,
+		user_domain.TokenFamilyClaim: familyId,
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodRS256, refreshClaims)
-	signedRefreshToken, err := refreshToken.SignedString(privateKey)
+	signedRefreshToken, err := jue.sign(refreshClaims)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign refresh token: %v", err)
 	}
@@ -103,21 +75,50 @@ func (jue *JWTUserEncoder) GenerateToken(user *user_domain.User) (*user_domain.T
 	return tokenDetails, nil
 }
 
-// DecryptToken verifies and parses a JWT token using the public key
-func (jue *JWTUserEncoder) DecryptToken(tokenString string) (jwt.Claims, error) {
-	// Load the public key
-	publicKey, err := loadPublicKey(jue.publicKeyPEM)
+// GenerateEmailVerificationToken generates the token identified by tokenId verifying the email of user
+func (jue *JWTUserEncoder) GenerateEmailVerificationToken(user *user_domain.User, tokenId string, expiresAt time.Time) (string, error) {
+	token, err := jue.sign(jwt.MapClaims{
+		"sub":                      user.Email,
+		"exp":                      expiresAt.Unix(),
+		"iat":                      user_domain.IssuedAtClaim(time.Now()),
+		"jti":                      tokenId,
+		user_domain.TokenTypeClaim: user_domain.EmailVerificationTokenType,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to load public key: %v", err)
+		return "", fmt.Errorf("failed to sign email verification token: %v", err)
 	}
 
-	// Parse and verify the token
+	return token, nil
+}
+
+// sign signs claims with the active key of the ring, naming it in the `kid` header.
+func (jue *JWTUserEncoder) sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = jue.issuer
+
+	key := jue.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Id
+
+	return token.SignedString(key.private)
+}
+
+// DecryptToken verifies and parses a JWT token using the key named by its `kid` header
+func (jue *JWTUserEncoder) DecryptToken(tokenString string) (jwt.Claims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Ensure the signing method is RS256
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		kid, ok := token.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("token without signing key id")
+		}
+		key, ok := jue.keys.Find(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+
+		// Ensure the token is signed with the algorithm of the key, never one it names itself
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return publicKey, nil
+		return key.public, nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %v", err)
@@ -128,5 +129,9 @@ func (jue *JWTUserEncoder) DecryptToken(tokenString string) (jwt.Claims, error)
 		return nil, fmt.Errorf("invalid token")
 	}
 
+	if mapClaims, ok := token.Claims.(jwt.MapClaims); !ok || !mapClaims.VerifyIssuer(jue.issuer, true) {
+		return nil, fmt.Errorf("invalid token issuer")
+	}
+
 	return token.Claims, nil
 }
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Key is a signing or verification key identified by the `kid` header of the tokens it signs.
+type Key struct {
+	Id     string
+	Method jwt.SigningMethod
+	// NotAfter is when a retired key stops being trusted, zero for keys that never expire.
+	NotAfter time.Time
+	// private is nil for verification only keys.
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// CanSign tells whether the private part of the key is known.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// Expired tells whether the key is no longer trusted at now.
+func (k *Key) Expired(now time.Time) bool {
+	return !k.NotAfter.IsZero() && !now.Before(k.NotAfter)
+}
+
+// Public returns the public part of the key.
+func (k *Key) Public() crypto.PublicKey {
+	return k.public
+}
+
+// ParseKey parses a PEM encoded RSA, ECDSA or Ed25519 key, either private or public. The password is only
+// used for legacy encrypted PEM blocks. An empty id is replaced by the RFC 7638 thumbprint of the key.
+func ParseKey(id string, pemBytes []byte, password string) (*Key, error) {
+	block, _ := pem.Decode([]byte(strings.Replace(string(pemBytes), `\n`, "\n", -1)))
+	if block == nil {
+		return nil, errors.New("failed to parse PEM block containing the key")
+	}
+
+	der := block.Bytes
+	// Legacy encrypted PEM blocks are deprecated, but they are what USER_PRIVATE_PEM_PASSWORD protects.
+	if x509.IsEncryptedPEMBlock(block) {
+		var err error
+		if der, err = x509.DecryptPEMBlock(block, []byte(password)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key: %v", err)
+		}
+	}
+
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v", err)
+		}
+		public = parsed
+	case "CERTIFICATE":
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %v", err)
+		}
+		public = certificate.PublicKey
+	case "RSA PUBLIC KEY":
+		parsed, err := x509.ParsePKCS1PublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v", err)
+		}
+		public = parsed
+	default:
+		parsed, err := parsePrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		private = parsed
+		public = parsed.(crypto.Signer).Public()
+	}
+
+	method, err := signingMethodFor(public)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{Id: id, Method: method, private: private, public: public}
+	if key.Id == "" {
+		if key.Id, err = Thumbprint(public); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+
+	return key, nil
+}
+
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("unsupported ECDSA curve %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+// KeyRing holds the key tokens are signed with and the keys they are verified with. Keys retired from
+// signing stay in the ring until the tokens they signed expire, so rotating keys signs nobody out, and
+// are no longer trusted once past their NotAfter.
+type KeyRing struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+func NewKeyRing(active *Key, verification ...*Key) (*KeyRing, error) {
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active key %s has no private key", active.Id)
+	}
+	if active.Expired(time.Now()) {
+		return nil, fmt.Errorf("active key %s expired at %s", active.Id, active.NotAfter)
+	}
+
+	keys := map[string]*Key{active.Id: active}
+	for _, key := range verification {
+		if _, ok := keys[key.Id]; ok {
+			return nil, fmt.Errorf("duplicated key %s", key.Id)
+		}
+		keys[key.Id] = key
+	}
+
+	return &KeyRing{active: active, keys: keys}, nil
+}
+
+// LoadKeyRing reads every `<kid>.pem` file of dir, signing with the key activeId and verifying with all of them.
+// Retired keys are named `<kid>@<yyyy-mm-dd>.pem` to stop trusting them from that date on, which should be at
+// least the refresh token TTL after they stopped signing.
+func LoadKeyRing(dir string, activeId string, password string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var active *Key
+	var verification []*Key
+	for _, path := range paths {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		id, notAfter, err := parseKeyFileName(filepath.Base(path))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		key, err := ParseKey(id, pemBytes, password)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		key.NotAfter = notAfter
+		if key.Id == activeId {
+			active = key
+			continue
+		}
+		verification = append(verification, key)
+	}
+	if active == nil {
+		return nil, fmt.Errorf("active key %s not found in %s", activeId, dir)
+	}
+
+	return NewKeyRing(active, verification...)
+}
+
+func parseKeyFileName(name string) (string, time.Time, error) {
+	id, date, found := strings.Cut(strings.TrimSuffix(name, ".pem"), "@")
+	if !found {
+		return id, time.Time{}, nil
+	}
+
+	notAfter, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid not after date %s: %v", date, err)
+	}
+
+	return id, notAfter, nil
+}
+
+// Active returns the key new tokens are signed with.
+func (kr *KeyRing) Active() *Key {
+	return kr.active
+}
+
+// Find returns the key identified by id, unless it expired.
+func (kr *KeyRing) Find(id string) (*Key, bool) {
+	key, ok := kr.keys[id]
+	if !ok || key.Expired(time.Now()) {
+		return nil, false
+	}
+
+	return key, true
+}
+
+// Keys returns every key of the ring that did not expire, sorted by id.
+func (kr *KeyRing) Keys() []*Key {
+	now := time.Now()
+	keys := make([]*Key, 0, len(kr.keys))
+	for _, key := range kr.keys {
+		if !key.Expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+
+	return keys
+}
@@ -0,0 +1,233 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func privatePEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicPEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func rsaKey(t *testing.T, id string) *auth.Key {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	key, err := auth.ParseKey(id, privatePEM(t, private), "")
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestParseKey_DetectsSigningMethod(t *testing.T) {
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		pem []byte
+		alg string
+	}{
+		"rsa private":     {pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivate)}), "RS256"},
+		"ecdsa private":   {privatePEM(t, ecPrivate), "ES384"},
+		"ed25519 private": {privatePEM(t, edPrivate), "EdDSA"},
+		"ed25519 public":  {publicPEM(t, edPrivate.Public()), "EdDSA"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			key, err := auth.ParseKey("key", c.pem, "")
+
+			require.NoError(t, err)
+			assert.Equal(t, c.alg, key.Method.Alg())
+		})
+	}
+}
+
+func TestParseKey_DefaultsIdToThumbprint(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	signing, err := auth.ParseKey("", privatePEM(t, private), "")
+	require.NoError(t, err)
+	verification, err := auth.ParseKey("", publicPEM(t, &private.PublicKey), "")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, signing.Id)
+	assert.Equal(t, signing.Id, verification.Id)
+}
+
+func TestKeyRing_JWKS_PublishesEveryKey(t *testing.T) {
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	retired, err := auth.ParseKey("retired", publicPEM(t, edPrivate.Public()), "")
+	require.NoError(t, err)
+
+	ring, err := auth.NewKeyRing(rsaKey(t, "active"), retired)
+	require.NoError(t, err)
+
+	jwks, err := ring.JWKS()
+
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "active", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+	assert.Equal(t, "retired", jwks.Keys[1].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+	assert.Equal(t, []string{"RS256", "EdDSA"}, ring.Algorithms())
+}
+
+func TestNewKeyRing_RequiresPrivateActiveKey(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	public, err := auth.ParseKey("public", publicPEM(t, &private.PublicKey), "")
+	require.NoError(t, err)
+
+	_, err = auth.NewKeyRing(public)
+
+	assert.Error(t, err)
+}
+
+func TestLoadKeyRing_ReadsKeysNamedByFile(t *testing.T) {
+	dir := t.TempDir()
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01.pem"), privatePEM(t, first), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-02.pem"), privatePEM(t, second), 0o600))
+
+	ring, err := auth.LoadKeyRing(dir, "2024-02", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02", ring.Active().Id)
+	assert.Equal(t, "ES256", ring.Active().Method.Alg())
+	_, ok := ring.Find("2024-01")
+	assert.True(t, ok)
+}
+
+func TestLoadKeyRing_StopsTrustingExpiredKeys(t *testing.T) {
+	dir := t.TempDir()
+	active, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	expired, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	retired, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-03.pem"), privatePEM(t, active), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01@2024-02-15.pem"), publicPEM(t, &expired.PublicKey), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-02@2999-01-01.pem"), publicPEM(t, &retired.PublicKey), 0o600))
+
+	ring, err := auth.LoadKeyRing(dir, "2024-03", "")
+	require.NoError(t, err)
+
+	_, ok := ring.Find("2024-01")
+	assert.False(t, ok)
+	_, ok = ring.Find("2024-02")
+	assert.True(t, ok)
+
+	jwks, err := ring.JWKS()
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2024-02", jwks.Keys[0].Kid)
+	assert.Equal(t, "2024-03", jwks.Keys[1].Kid)
+}
+
+func TestJWTUserEncoder_VerifiesTokensOfRetiredKeys(t *testing.T) {
+	old := rsaKey(t, "old")
+	oldRing, err := auth.NewKeyRing(old)
+	require.NoError(t, err)
+	token, err := auth.NewJWTUserEncoder(oldRing, "https://users.example.com").GenerateToken(&user_domain.User{Email: "johndoe@example.com"})
+	require.NoError(t, err)
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	current, err := auth.ParseKey("current", privatePEM(t, edPrivate), "")
+	require.NoError(t, err)
+	rotatedRing, err := auth.NewKeyRing(current, old)
+	require.NoError(t, err)
+	encoder := auth.NewJWTUserEncoder(rotatedRing, "https://users.example.com")
+
+	claims, err := encoder.DecryptToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "johndoe@example.com", claims.(jwt.MapClaims)["sub"])
+
+	rotated, err := encoder.GenerateToken(&user_domain.User{Email: "johndoe@example.com"})
+	require.NoError(t, err)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(rotated.AccessToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "current", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	_, err = auth.NewJWTUserEncoder(oldRing, "https://users.example.com").DecryptToken(rotated.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestJWTUserEncoder_RejectsOtherIssuers(t *testing.T) {
+	ring, err := auth.NewKeyRing(rsaKey(t, "key"))
+	require.NoError(t, err)
+	token, err := auth.NewJWTUserEncoder(ring, "https://other.example.com").GenerateToken(&user_domain.User{Email: "johndoe@example.com"})
+	require.NoError(t, err)
+
+	_, err = auth.NewJWTUserEncoder(ring, "https://users.example.com").DecryptToken(token.AccessToken)
+
+	assert.Error(t, err)
+}
+
+func TestJWTUserEncoder_RejectsTokensWithoutKeyIdOrIssuer(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := auth.ParseKey("key", privatePEM(t, private), "")
+	require.NoError(t, err)
+	ring, err := auth.NewKeyRing(key)
+	require.NoError(t, err)
+	encoder := auth.NewJWTUserEncoder(ring, "https://users.example.com")
+
+	sign := func(kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(private)
+		require.NoError(t, err)
+
+		return signed
+	}
+
+	_, err = encoder.DecryptToken(sign("", jwt.MapClaims{"sub": "johndoe@example.com", "iss": "https://users.example.com"}))
+	assert.Error(t, err)
+
+	_, err = encoder.DecryptToken(sign("key", jwt.MapClaims{"sub": "johndoe@example.com"}))
+	assert.Error(t, err)
+
+	_, err = encoder.DecryptToken(sign("key", jwt.MapClaims{"sub": "johndoe@example.com", "iss": "https://users.example.com"}))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"time"
+)
+
+// AuthMethod tells how a Principal was authenticated.
+type AuthMethod string
+
+const (
+	// AuthMethodBearer is a principal presenting an access token.
+	AuthMethodBearer AuthMethod = "bearer"
+)
+
+// Principal is who a request or a message is handled on behalf of. Gin handlers put it in the context of
+// the request, which they pass to the buses, so bus handlers read it the same way.
+type Principal struct {
+	UserId         string
+	Email          string
+	Roles          []user_domain.Role
+	TokenId        string
+	TokenExpiresAt time.Time
+	AuthMethod     AuthMethod
+	// Scopes are the permissions granted to the roles when the principal was authenticated.
+	Scopes []user_domain.Permission
+}
+
+// HasRole tells whether role is one of the roles of the principal.
+func (p *Principal) HasRole(role user_domain.Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can tells whether the scopes of the principal grant permission.
+func (p *Principal) Can(permission user_domain.Permission) bool {
+	return user_domain.Grants(p.Scopes, permission)
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFrom returns the principal carried by ctx, ok is false for anonymous requests and for messages
+// the system handles on its own, such as scheduled commands.
+func PrincipalFrom(ctx context.Context) (principal *Principal, ok bool) {
+	principal, ok = ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// IdempotencyOwner returns the id of the user authenticated in ctx, empty for anonymous requests. Keys
+// of idempotent commands are scoped to it rather than to what the command claims about its sender.
+func IdempotencyOwner(ctx context.Context) string {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return ""
+	}
+
+	return principal.UserId
+}
+
+type systemContextKey struct{}
+
+// AsSystem returns a copy of ctx on which the system acts on its own rather than on behalf of a principal,
+// such as the background workers handling scheduled, queued and published messages.
+func AsSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemContextKey{}, true)
+}
+
+// IsSystem tells whether ctx was marked by AsSystem.
+func IsSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemContextKey{}).(bool)
+	return system
+}
+
+// Authorize fails with Forbidden unless ctx is marked AsSystem or the principal in ctx is the user email
+// or is granted permission. Without a principal nor the mark nothing is authorized.
+func Authorize(ctx context.Context, email string, permission user_domain.Permission) error {
+	if IsSystem(ctx) {
+		return nil
+	}
+
+	principal, ok := PrincipalFrom(ctx)
+	if ok && (principal.Email == email || principal.Can(permission)) {
+		return nil
+	}
+
+	return NewForbidden(permission)
+}
+
+// Forbidden is a principal lacking the permission to perform an action.
+type Forbidden struct {
+	message    string
+	permission user_domain.Permission
+}
+
+func (f Forbidden) Error() string {
+	return f.message
+}
+
+func (f Forbidden) Kind() bus.ErrorKind {
+	return bus.KindForbidden
+}
+
+func NewForbidden(permission user_domain.Permission) Forbidden {
+	return Forbidden{message: fmt.Sprintf("Missing permission %s", permission), permission: permission}
+}
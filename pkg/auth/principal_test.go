@@ -0,0 +1,39 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	user := &auth.Principal{Email: "johndoe@example.com", Scopes: []user_domain.Permission{user_domain.PermissionProfileRead}}
+	admin := &auth.Principal{Email: "admin@example.com", Scopes: []user_domain.Permission{"users:*"}}
+
+	cases := map[string]struct {
+		ctx     context.Context
+		allowed bool
+	}{
+		"system":     {auth.AsSystem(context.Background()), true},
+		"anonymous":  {context.Background(), false},
+		"same user":  {auth.WithPrincipal(context.Background(), user), true},
+		"granted":    {auth.WithPrincipal(context.Background(), admin), true},
+		"other user": {auth.WithPrincipal(context.Background(), &auth.Principal{Email: "janedoe@example.com"}), false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := auth.Authorize(c.ctx, "johndoe@example.com", user_domain.PermissionUsersRead)
+
+			if c.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.IsType(t, auth.Forbidden{}, err)
+			assert.Equal(t, bus.KindForbidden, bus.KindOf(err))
+		})
+	}
+}
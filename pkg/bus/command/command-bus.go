@@ -2,39 +2,71 @@ package command
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
 	"github.com/mik3lon/starter-template/pkg/bus"
 	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
 	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 type Bus interface {
 	RegisterCommand(command bus.Dto, handler CommandHandler) error
 	Dispatch(ctx context.Context, dto bus.Dto) error
 	DispatchAsync(ctx context.Context, dto bus.Dto) error
+	DispatchAt(ctx context.Context, dto bus.Dto, at time.Time) error
+	DispatchAfter(ctx context.Context, dto bus.Dto, delay time.Duration) error
 	ProcessFailed(ctx context.Context)
 }
 
+// Scheduler keeps the commands dispatched for later until they are due, see CommandBus.SetScheduler.
+type Scheduler interface {
+	DispatchAt(ctx context.Context, dto bus.Dto, at time.Time) error
+}
+
 type CommandBus struct {
-	handlers       map[string]CommandHandler
-	lock           sync.Mutex
-	l              shared_image_infrastructure.Logger
-	failedCommands chan *FailedCommand
+	handlers        map[string]CommandHandler
+	registry        *bus.Registry
+	lock            sync.Mutex
+	l               shared_image_infrastructure.Logger
+	failedCommands  chan *FailedCommand
+	middlewares     []namedMiddleware
+	retryPolicies   map[string]RetryPolicy
+	retryPolicy     RetryPolicy
+	deadLetterStore DeadLetterStore
+	timeouts        map[string]time.Duration
+	timeout         time.Duration
+	counters        map[string]*bus.Counters
+	scheduler       Scheduler
 }
 
 func InitCommandBus(l shared_image_infrastructure.Logger) *CommandBus {
 	return &CommandBus{
-		handlers:       make(map[string]CommandHandler, 0),
-		lock:           sync.Mutex{},
-		l:              l,
-		failedCommands: make(chan *FailedCommand),
+		handlers:        make(map[string]CommandHandler, 0),
+		registry:        bus.NewRegistry(),
+		lock:            sync.Mutex{},
+		l:               l,
+		failedCommands:  make(chan *FailedCommand, 100),
+		retryPolicies:   make(map[string]RetryPolicy, 0),
+		retryPolicy:     DefaultRetryPolicy(),
+		deadLetterStore: NewInMemoryDeadLetterStore(),
+		timeouts:        make(map[string]time.Duration, 0),
+		counters:        make(map[string]*bus.Counters, 0),
 	}
 }
 
 type FailedCommand struct {
+	// ctx is the detached context the command was dispatched with, retries keep its values.
+	ctx            context.Context
+	commandName    string
 	command        bus.Dto
 	handler        CommandHandler
 	timesProcessed int
+	errors         []string
+	lastErr        error
 }
 
 type CommandAlreadyRegistered struct {
@@ -67,27 +99,135 @@ func (bus *CommandBus) RegisterCommand(command bus.Dto, handler CommandHandler)
 	bus.lock.Lock()
 	defer bus.lock.Unlock()
 
-	commandName, err := bus.commandName(command)
+	commandName, err := commandName(command)
 	if err != nil {
 		return err
 	}
 
+	// The registry tells which types collide on the name, the handlers only catch the same type twice.
+	if err := bus.registry.Register(command); err != nil {
+		return err
+	}
+
 	if _, ok := bus.handlers[*commandName]; ok {
 		return NewCommandAlreadyRegistered("Command already registered", *commandName)
 	}
 
 	bus.handlers[*commandName] = handler
+	bus.counters[*commandName] = newCounters()
+
+	return nil
+}
+
+// Registry exposes the commands known by the bus so transports can (de)serialize them.
+func (bus *CommandBus) Registry() *bus.Registry {
+	return bus.registry
+}
+
+// Handlers lists the registered commands, sorted by name, with their handler and counters.
+func (bus *CommandBus) Handlers() []bus.HandlerInfo {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	return handlerInfos(bus.handlers, bus.counters)
+}
+
+func handlerInfos(handlers map[string]CommandHandler, counters map[string]*bus.Counters) []bus.HandlerInfo {
+	infos := make([]bus.HandlerInfo, 0, len(handlers))
+	for name, handler := range handlers {
+		infos = append(infos, counters[name].Info(name, handler))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
+
+func newCounters() *bus.Counters {
+	return &bus.Counters{}
+}
+
+func (bus *CommandBus) countersFor(commandName string) *bus.Counters {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	if counters, ok := bus.counters[commandName]; ok {
+		return counters
+	}
+
+	return newCounters()
+}
+
+// SetRetryPolicy overrides the default RetryPolicy for the given command.
+func (bus *CommandBus) SetRetryPolicy(command bus.Dto, policy RetryPolicy) error {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	commandName, err := commandName(command)
+	if err != nil {
+		return err
+	}
+
+	bus.retryPolicies[*commandName] = policy
+
+	return nil
+}
+
+// SetTimeout bounds the execution of the handler of the given command, once expired the bus
+// returns a bus.HandlerTimeout.
+func (bus *CommandBus) SetTimeout(command bus.Dto, timeout time.Duration) error {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	commandName, err := commandName(command)
+	if err != nil {
+		return err
+	}
+
+	bus.timeouts[*commandName] = timeout
 
 	return nil
 }
 
+// SetDefaultTimeout bounds the execution of the commands without a timeout of their own, zero means no bound.
+func (bus *CommandBus) SetDefaultTimeout(timeout time.Duration) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.timeout = timeout
+}
+
+// SetDefaultRetryPolicy replaces the RetryPolicy used by commands without one of their own.
+func (bus *CommandBus) SetDefaultRetryPolicy(policy RetryPolicy) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.retryPolicy = policy
+}
+
+// SetDeadLetterStore replaces the in memory store where commands exhausting their retries land.
+func (bus *CommandBus) SetDeadLetterStore(store DeadLetterStore) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.deadLetterStore = store
+}
+
+// SetScheduler makes DispatchAt persist the commands in scheduler instead of keeping them in memory.
+func (bus *CommandBus) SetScheduler(scheduler Scheduler) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.scheduler = scheduler
+}
+
 func (bus *CommandBus) Dispatch(ctx context.Context, command bus.Dto) error {
-	commandName, err := bus.commandName(command)
+	commandName, err := commandName(command)
 	if err != nil {
 		return err
 	}
 
 	if handler, ok := bus.handlers[*commandName]; ok {
+		bus.countersFor(*commandName).Dispatched()
 		err := bus.doHandle(ctx, handler, command)
 		if err != nil {
 			return err
@@ -100,13 +240,17 @@ func (bus *CommandBus) Dispatch(ctx context.Context, command bus.Dto) error {
 }
 
 func (bus *CommandBus) DispatchAsync(ctx context.Context, command bus.Dto) error {
-	commandName, err := bus.commandName(command)
+	commandName, err := commandName(command)
 	if err != nil {
 		return err
 	}
 
 	if handler, ok := bus.handlers[*commandName]; ok {
-		go bus.doHandleAsync(ctx, handler, command)
+		counters := bus.countersFor(*commandName)
+		counters.Dispatched()
+		counters.Started()
+		// The dispatcher, usually an HTTP request, may be done long before the command.
+		go bus.doHandleAsync(detach(ctx), *commandName, handler, command)
 
 		return nil
 	}
@@ -114,53 +258,271 @@ func (bus *CommandBus) DispatchAsync(ctx context.Context, command bus.Dto) error
 	return NewCommandNotRegistered("Command not registered", *commandName)
 }
 
+// DispatchAt dispatches command asynchronously once at is reached. Without a Scheduler the command
+// waits in memory and is lost if the process stops before.
+func (bus *CommandBus) DispatchAt(ctx context.Context, command bus.Dto, at time.Time) error {
+	bus.lock.Lock()
+	scheduler := bus.scheduler
+	bus.lock.Unlock()
+	if scheduler != nil {
+		return scheduler.DispatchAt(ctx, command, at)
+	}
+
+	commandName, err := commandName(command)
+	if err != nil {
+		return err
+	}
+	if _, ok := bus.handlers[*commandName]; !ok {
+		return NewCommandNotRegistered("Command not registered", *commandName)
+	}
+
+	ctx = detach(ctx)
+	time.AfterFunc(time.Until(at), func() {
+		if err := bus.DispatchAsync(ctx, command); err != nil {
+			bus.l.Error(ctx, "error dispatching scheduled command", map[string]interface{}{"error": err.Error(), "command": *commandName})
+		}
+	})
+
+	return nil
+}
+
+func (bus *CommandBus) DispatchAfter(ctx context.Context, command bus.Dto, delay time.Duration) error {
+	return bus.DispatchAt(ctx, command, time.Now().Add(delay))
+}
+
 func (bus *CommandBus) doHandle(ctx context.Context, handler CommandHandler, command bus.Dto) error {
-	return handler.Handle(ctx, command)
+	name, err := commandName(command)
+	if err != nil {
+		return err
+	}
+
+	err = handleWithTimeout(ctx, *name, bus.timeoutFor(*name), bus.chain(handler, command), command)
+	if err != nil {
+		bus.countersFor(*name).Failed()
+		logPanic(ctx, bus.l, *name, err)
+	}
+
+	return err
+}
+
+// redispatch handles a command again after a failure.
+func (bus *CommandBus) redispatch(ctx context.Context, command bus.Dto) error {
+	commandName, err := commandName(command)
+	if err != nil {
+		return err
+	}
+
+	bus.lock.Lock()
+	handler, ok := bus.handlers[*commandName]
+	bus.lock.Unlock()
+	if !ok {
+		return NewCommandNotRegistered("Command not registered", *commandName)
+	}
+
+	bus.countersFor(*commandName).Retried()
+
+	return bus.doHandle(ctx, handler, command)
+}
+
+func (bus *CommandBus) timeoutFor(commandName string) time.Duration {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	if timeout, ok := bus.timeouts[commandName]; ok {
+		return timeout
+	}
+
+	return bus.timeout
+}
+
+func handleWithTimeout(ctx context.Context, name string, timeout time.Duration, next HandlerFunc, command bus.Dto) error {
+	_, err := bus.WithTimeout(ctx, name, timeout, func(ctx context.Context) (struct{}, error) {
+		return bus.Recover(name, func() (struct{}, error) {
+			return struct{}{}, next(ctx, command)
+		})
+	})
+
+	return err
 }
 
-func (bus *CommandBus) doHandleAsync(ctx context.Context, handler CommandHandler, command bus.Dto) {
+func logPanic(ctx context.Context, l shared_image_infrastructure.Logger, name string, err error) {
+	var handlerPanic bus.HandlerPanic
+	if errors.As(err, &handlerPanic) {
+		l.Error(ctx, "command handler panicked", map[string]interface{}{
+			"command": name,
+			"panic":   fmt.Sprint(handlerPanic.Value),
+			"stack":   handlerPanic.Stack,
+		})
+	}
+}
+
+func detach(ctx context.Context) context.Context {
+	return bus.Detach(ctx)
+}
+
+func (bus *CommandBus) doHandleAsync(ctx context.Context, commandName string, handler CommandHandler, command bus.Dto) {
 	err := bus.doHandle(ctx, handler, command)
+	if err == nil {
+		bus.countersFor(commandName).Finished()
+		return
+	}
 
-	if err != nil {
-		bus.failedCommands <- &FailedCommand{
-			command:        command,
-			handler:        handler,
-			timesProcessed: 1,
-		}
-		bus.l.Error(ctx, "error_message", map[string]interface{}{"error": err.Error()})
+	bus.l.Error(ctx, "error_message", map[string]interface{}{"error": err.Error(), "command": commandName})
+	failedCommand := &FailedCommand{
+		ctx:            ctx,
+		commandName:    commandName,
+		command:        command,
+		handler:        handler,
+		timesProcessed: 1,
+		errors:         []string{err.Error()},
+		lastErr:        err,
+	}
+
+	select {
+	case bus.failedCommands <- failedCommand:
+	default:
+		// ProcessFailed is not running or is behind, waiting for it would leak this goroutine.
+		bus.l.Warn(ctx, "failed commands buffer full, dead lettering command", map[string]interface{}{"command": commandName})
+		bus.deadLetter(ctx, failedCommand)
+		bus.countersFor(commandName).Finished()
 	}
 }
 
-func (bus *CommandBus) commandName(cmd interface{}) (*string, error) {
+func commandName(cmd bus.Dto) (*string, error) {
 	value := reflect.ValueOf(cmd)
 
 	if value.Kind() != reflect.Ptr || !value.IsNil() && value.Elem().Kind() != reflect.Struct {
 		return nil, CommandNotValid{"only pointer to commands are allowed"}
 	}
 
-	name := value.String()
+	name := bus.NameOf(cmd)
 
 	return &name, nil
 }
 
+// ProcessFailed retries failed async commands following their RetryPolicy until ctx is done,
+// moving the ones that exhaust it to the DeadLetterStore.
 func (bus *CommandBus) ProcessFailed(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			close(bus.failedCommands)
 			bus.l.Warn(ctx, "exiting safely failed commands consumer", map[string]interface{}{"error": ctx.Err().Error()})
 			return
 		case failedCommand := <-bus.failedCommands:
-			if failedCommand.timesProcessed >= 3 {
-				continue
-			}
-
-			failedCommand.timesProcessed++
-			if err := bus.doHandle(ctx, failedCommand.handler, failedCommand.command); err != nil {
-				bus.l.Warn(ctx, "failing processing command", map[string]interface{}{"error": ctx.Err().Error()})
-			}
+			go bus.retry(ctx, failedCommand)
+		}
+	}
+}
+
+func (bus *CommandBus) retry(ctx context.Context, failedCommand *FailedCommand) {
+	policy := bus.retryPolicyFor(failedCommand.commandName)
+	counters := bus.countersFor(failedCommand.commandName)
+	defer counters.Finished()
+
+	for failedCommand.timesProcessed < policy.MaxAttempts && policy.IsRetryable(failedCommand.lastErr) {
+		select {
+		case <-ctx.Done():
+			bus.deadLetter(ctx, failedCommand)
+			return
+		case <-time.After(policy.Backoff(failedCommand.timesProcessed)):
+		}
+
+		failedCommand.timesProcessed++
+		counters.Retried()
+		err := bus.doHandle(failedCommand.ctx, failedCommand.handler, failedCommand.command)
+		if err == nil {
+			return
+		}
+
+		failedCommand.errors = append(failedCommand.errors, err.Error())
+		failedCommand.lastErr = err
+		bus.l.Warn(ctx, "failing processing command", map[string]interface{}{
+			"error":   err.Error(),
+			"command": failedCommand.commandName,
+			"attempt": failedCommand.timesProcessed,
+		})
+	}
+
+	bus.deadLetter(ctx, failedCommand)
+}
+
+func (bus *CommandBus) retryPolicyFor(commandName string) RetryPolicy {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	if policy, ok := bus.retryPolicies[commandName]; ok {
+		return policy
+	}
+
+	return bus.retryPolicy
+}
+
+func (bus *CommandBus) deadLetter(ctx context.Context, failedCommand *FailedCommand) {
+	_ = bus.storeDeadLetter(ctx, &DeadLetter{
+		Id:          uuid.NewString(),
+		CommandName: failedCommand.commandName,
+		Command:     failedCommand.command,
+		Errors:      failedCommand.errors,
+		Attempts:    failedCommand.timesProcessed,
+		FailedAt:    time.Now(),
+	})
+}
+
+func (bus *CommandBus) storeDeadLetter(ctx context.Context, deadLetter *DeadLetter) error {
+	bus.lock.Lock()
+	store := bus.deadLetterStore
+	bus.lock.Unlock()
+
+	// The command may be dead lettered because ctx is done, it still has to be stored.
+	if err := store.Save(context.WithoutCancel(ctx), deadLetter); err != nil {
+		bus.l.Error(ctx, "error storing dead letter", map[string]interface{}{
+			"error":   err.Error(),
+			"command": deadLetter.CommandName,
+		})
+		return err
+	}
+
+	bus.l.Error(ctx, "command moved to dead letter store", map[string]interface{}{
+		"dead_letter_id": deadLetter.Id,
+		"command":        deadLetter.CommandName,
+		"errors":         deadLetter.Errors,
+	})
+
+	return nil
+}
+
+// DeadLetters lists the commands that exhausted their RetryPolicy.
+func (bus *CommandBus) DeadLetters(ctx context.Context) ([]*DeadLetter, error) {
+	bus.lock.Lock()
+	store := bus.deadLetterStore
+	bus.lock.Unlock()
+
+	return store.FindAll(ctx)
+}
+
+// ReplayDeadLetter dispatches a dead lettered command again and removes it from the store once it succeeds.
+func (bus *CommandBus) ReplayDeadLetter(ctx context.Context, id string) error {
+	bus.lock.Lock()
+	store := bus.deadLetterStore
+	bus.lock.Unlock()
+
+	deadLetter, err := store.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	command := deadLetter.Command
+	if command == nil {
+		if command, err = bus.registry.Decode(deadLetter.CommandName, deadLetter.Payload); err != nil {
+			return err
 		}
 	}
+
+	if err := bus.Dispatch(ctx, command); err != nil {
+		return err
+	}
+
+	return store.Delete(ctx, id)
 }
 
 type CommandNotValid struct {
@@ -0,0 +1,417 @@
+package command_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCommand struct {
+	skipped []string
+}
+
+func (c testCommand) Id() string {
+	return "test-command"
+}
+
+func (c testCommand) SkipMiddlewares() []string {
+	return c.skipped
+}
+
+type recordingHandler struct {
+	calls *[]string
+	done  chan struct{}
+}
+
+func (h recordingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	*h.calls = append(*h.calls, "handler")
+	if h.done != nil {
+		close(h.done)
+	}
+	return nil
+}
+
+func recordingMiddleware(name string, calls *[]string) command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(ctx context.Context, c bus.Dto) error {
+			*calls = append(*calls, name)
+			return next(ctx, c)
+		}
+	}
+}
+
+func TestCommandBus_Dispatch_RunsMiddlewaresInRegistrationOrder(t *testing.T) {
+	var calls []string
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use("first", recordingMiddleware("first", &calls))
+	cb.Use("second", recordingMiddleware("second", &calls))
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, recordingHandler{calls: &calls}))
+
+	err := cb.Dispatch(context.Background(), &testCommand{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestCommandBus_Dispatch_SkipsOptedOutMiddlewares(t *testing.T) {
+	var calls []string
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use("first", recordingMiddleware("first", &calls))
+	cb.Use("second", recordingMiddleware("second", &calls))
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, recordingHandler{calls: &calls}))
+
+	err := cb.Dispatch(context.Background(), &testCommand{skipped: []string{"first"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second", "handler"}, calls)
+}
+
+func TestCommandBus_DispatchAsync_RunsMiddlewares(t *testing.T) {
+	var calls []string
+	var mu sync.Mutex
+	done := make(chan struct{})
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use("first", func(next command.HandlerFunc) command.HandlerFunc {
+		return func(ctx context.Context, c bus.Dto) error {
+			mu.Lock()
+			calls = append(calls, "first")
+			mu.Unlock()
+			return next(ctx, c)
+		}
+	})
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, recordingHandler{calls: &calls, done: done}))
+
+	err := cb.DispatchAsync(context.Background(), &testCommand{})
+	<-done
+
+	assert.NoError(t, err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"first", "handler"}, calls)
+}
+
+type failingHandler struct {
+	lock     sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (h *failingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.calls++
+	if h.calls <= h.failures {
+		return h.err
+	}
+	return nil
+}
+
+func (h *failingHandler) Calls() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.calls
+}
+
+func fastRetryPolicy(maxAttempts int) command.RetryPolicy {
+	return command.RetryPolicy{MaxAttempts: maxAttempts, InitialBackoff: time.Millisecond, Multiplier: 2}
+}
+
+func TestCommandBus_ProcessFailed_MovesExhaustedCommandsToDeadLetterStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := command.NewInMemoryDeadLetterStore()
+	handler := &failingHandler{failures: 10, err: errors.New("boom")}
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.SetDeadLetterStore(store)
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, handler))
+	require.NoError(t, cb.SetRetryPolicy(&testCommand{}, fastRetryPolicy(3)))
+	go cb.ProcessFailed(ctx)
+
+	require.NoError(t, cb.DispatchAsync(ctx, &testCommand{}))
+
+	var deadLetters []*command.DeadLetter
+	require.Eventually(t, func() bool {
+		deadLetters, _ = store.FindAll(ctx)
+		return len(deadLetters) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 3, deadLetters[0].Attempts)
+	assert.Equal(t, []string{"boom", "boom", "boom"}, deadLetters[0].Errors)
+	assert.Equal(t, 3, handler.Calls())
+}
+
+func TestCommandBus_ProcessFailed_DoesNotRetryNonRetryableErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := command.NewInMemoryDeadLetterStore()
+	handler := &failingHandler{failures: 10, err: command.NewNonRetryable(errors.New("invalid"))}
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.SetDeadLetterStore(store)
+	cb.SetDefaultRetryPolicy(fastRetryPolicy(5))
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, handler))
+	go cb.ProcessFailed(ctx)
+
+	require.NoError(t, cb.DispatchAsync(ctx, &testCommand{}))
+
+	require.Eventually(t, func() bool {
+		deadLetters, _ := store.FindAll(ctx)
+		return len(deadLetters) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, handler.Calls())
+}
+
+func TestCommandBus_DispatchAsync_DeadLettersWhenFailedCommandsAreNotProcessed(t *testing.T) {
+	store := command.NewInMemoryDeadLetterStore()
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.SetDeadLetterStore(store)
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, &failingHandler{failures: 1000, err: errors.New("boom")}))
+
+	// Without ProcessFailed running only the first 100 failures fit in the buffer.
+	for i := 0; i < 105; i++ {
+		require.NoError(t, cb.DispatchAsync(context.Background(), &testCommand{}))
+	}
+
+	require.Eventually(t, func() bool {
+		deadLetters, _ := store.FindAll(context.Background())
+		return len(deadLetters) == 5
+	}, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return cb.Handlers()[0].InFlight == 100
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCommandBus_ReplayDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	store := command.NewInMemoryDeadLetterStore()
+	handler := &failingHandler{}
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.SetDeadLetterStore(store)
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, handler))
+	require.NoError(t, store.Save(ctx, &command.DeadLetter{Id: "dead-letter", Command: &testCommand{}}))
+
+	err := cb.ReplayDeadLetter(ctx, "dead-letter")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handler.Calls())
+	_, err = store.Find(ctx, "dead-letter")
+	assert.ErrorAs(t, err, new(command.DeadLetterNotFound))
+}
+
+type typedTestCommandHandler struct {
+	handled *testCommand
+}
+
+func (h *typedTestCommandHandler) Handle(ctx context.Context, c *testCommand) error {
+	h.handled = c
+	return nil
+}
+
+func TestRegister_DispatchesTypedCommand(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	handler := &typedTestCommandHandler{}
+	require.NoError(t, command.Register[*testCommand](cb, handler))
+
+	c := &testCommand{}
+	err := cb.Dispatch(context.Background(), c)
+
+	assert.NoError(t, err)
+	assert.Same(t, c, handler.handled)
+}
+
+type blockingHandler struct{}
+
+func (h blockingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCommandBus_Dispatch_ReturnsHandlerTimeout(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, blockingHandler{}))
+	require.NoError(t, cb.SetTimeout(&testCommand{}, 10*time.Millisecond))
+
+	err := cb.Dispatch(context.Background(), &testCommand{})
+
+	assert.ErrorAs(t, err, new(bus.HandlerTimeout))
+}
+
+type contextValueHandler struct {
+	values chan interface{}
+}
+
+func (h contextValueHandler) Handle(ctx context.Context, c bus.Dto) error {
+	h.values <- ctx.Value(contextKey{})
+	h.values <- ctx.Err()
+	return nil
+}
+
+type contextKey struct{}
+
+func TestCommandBus_DispatchAsync_DetachesContext(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	handler := contextValueHandler{values: make(chan interface{}, 2)}
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, handler))
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "trace"))
+	cancel()
+
+	require.NoError(t, cb.DispatchAsync(ctx, &testCommand{}))
+
+	assert.Equal(t, "trace", <-handler.values)
+	assert.Nil(t, <-handler.values)
+}
+
+func TestCommandBus_Handlers_ReportsCounters(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	handler := &failingHandler{failures: 2, err: errors.New("boom")}
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, handler))
+
+	_ = cb.Dispatch(context.Background(), &testCommand{})
+	_ = cb.Dispatch(context.Background(), &testCommand{})
+
+	handlers := cb.Handlers()
+	require.Len(t, handlers, 1)
+	assert.Equal(t, "test-command", handlers[0].Name)
+	assert.Equal(t, "*command_test.failingHandler", handlers[0].Handler)
+	assert.Equal(t, uint64(2), handlers[0].Dispatched)
+	assert.Equal(t, uint64(2), handlers[0].Failed)
+}
+
+type validatedCommand struct {
+	Email string `validate:"required,email"`
+}
+
+func TestCommandBus_Dispatch_RejectsInvalidCommands(t *testing.T) {
+	var calls []string
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use(bus.ValidationMiddlewareName, command.ValidationMiddleware())
+	require.NoError(t, cb.RegisterCommand(&validatedCommand{}, recordingHandler{calls: &calls}))
+
+	err := cb.Dispatch(context.Background(), &validatedCommand{})
+
+	assert.IsType(t, bus.ValidationError{}, err)
+	assert.Equal(t, []bus.FieldError{{Field: "email", Message: "is required"}}, err.(bus.ValidationError).Fields)
+	assert.Empty(t, calls)
+}
+
+type recordingTransactionManager struct {
+	calls *[]string
+}
+
+func (m recordingTransactionManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	*m.calls = append(*m.calls, "begin")
+	if err := fn(ctx); err != nil {
+		*m.calls = append(*m.calls, "rollback")
+		return err
+	}
+	*m.calls = append(*m.calls, "commit")
+	return nil
+}
+
+func TestCommandBus_Dispatch_RunsCommandsInUnitOfWork(t *testing.T) {
+	var calls []string
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use(command.TransactionMiddlewareName, command.TransactionMiddleware(recordingTransactionManager{calls: &calls}))
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, recordingHandler{calls: &calls}))
+	require.NoError(t, cb.RegisterCommand(&validatedCommand{}, &failingHandler{failures: 1, err: errors.New("boom")}))
+
+	require.NoError(t, cb.Dispatch(context.Background(), &testCommand{}))
+	assert.Error(t, cb.Dispatch(context.Background(), &validatedCommand{}))
+
+	assert.Equal(t, []string{"begin", "handler", "commit", "begin", "rollback"}, calls)
+}
+
+func TestCommandBus_DispatchAfter_RunsCommandOnceDue(t *testing.T) {
+	var calls []string
+	done := make(chan struct{})
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, recordingHandler{calls: &calls, done: done}))
+
+	dispatchedAt := time.Now()
+	require.NoError(t, cb.DispatchAfter(context.Background(), &testCommand{}, 50*time.Millisecond))
+
+	select {
+	case <-done:
+		assert.GreaterOrEqual(t, time.Since(dispatchedAt), 50*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("scheduled command was not run")
+	}
+}
+
+type panickingHandler struct{}
+
+func (h panickingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	panic("boom")
+}
+
+func TestCommandBus_Dispatch_RecoversHandlerPanics(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, panickingHandler{}))
+	require.NoError(t, cb.SetTimeout(&testCommand{}, time.Second))
+
+	err := cb.Dispatch(context.Background(), &testCommand{})
+
+	assert.IsType(t, bus.HandlerPanic{}, err)
+}
+
+func TestCommandBus_DispatchAsync_RecoversHandlerPanics(t *testing.T) {
+	store := command.NewInMemoryDeadLetterStore()
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.SetDefaultRetryPolicy(command.RetryPolicy{MaxAttempts: 1})
+	cb.SetDeadLetterStore(store)
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, panickingHandler{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cb.ProcessFailed(ctx)
+
+	require.NoError(t, cb.DispatchAsync(context.Background(), &testCommand{}))
+
+	assert.Eventually(t, func() bool {
+		deadLetters, err := store.FindAll(context.Background())
+		return err == nil && len(deadLetters) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRetryPolicy_IsRetryable_SkipsValidationErrors(t *testing.T) {
+	policy := command.DefaultRetryPolicy()
+
+	assert.False(t, policy.IsRetryable(bus.NewValidationError(bus.FieldError{Field: "email", Message: "is required"})))
+	assert.True(t, policy.IsRetryable(bus.NewHandlerTimeout("cmd", time.Second)))
+}
+
+type collidingCommand struct{}
+
+func (c collidingCommand) Id() string {
+	return "test-command"
+}
+
+func TestCommandBus_RegisterCommand_NamesCollidingTypes(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, &failingHandler{}))
+
+	err := cb.RegisterCommand(&collidingCommand{}, &failingHandler{})
+
+	assert.IsType(t, bus.DtoAlreadyRegistered{}, err)
+	assert.ErrorContains(t, err, "*command_test.testCommand")
+	assert.ErrorContains(t, err, "*command_test.collidingCommand")
+}
+
+func TestCommandBus_RegisterCommand_FailsOnSameCommandTwice(t *testing.T) {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&testCommand{}, &failingHandler{}))
+
+	err := cb.RegisterCommand(&testCommand{}, &failingHandler{})
+
+	assert.IsType(t, command.CommandAlreadyRegistered{}, err)
+}
@@ -0,0 +1,98 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeadLetter is an async command that exhausted its RetryPolicy.
+type DeadLetter struct {
+	Id          string
+	CommandName string
+	// Command is only set by stores that keep the command in memory, the rest rebuild it from Payload.
+	Command  bus.Dto
+	Payload  json.RawMessage
+	Errors   []string
+	Attempts int
+	FailedAt time.Time
+}
+
+type DeadLetterStore interface {
+	Save(ctx context.Context, deadLetter *DeadLetter) error
+	Find(ctx context.Context, id string) (*DeadLetter, error)
+	FindAll(ctx context.Context) ([]*DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type DeadLetterNotFound struct {
+	message string
+	id      string
+}
+
+func (i DeadLetterNotFound) Error() string {
+	return i.message
+}
+
+func NewDeadLetterNotFound(id string) DeadLetterNotFound {
+	return DeadLetterNotFound{message: "Dead letter not found", id: id}
+}
+
+// InMemoryDeadLetterStore is a DeadLetterStore that does not survive restarts, meant for tests and local runs.
+type InMemoryDeadLetterStore struct {
+	deadLetters map[string]*DeadLetter
+	lock        sync.Mutex
+}
+
+func NewInMemoryDeadLetterStore() *InMemoryDeadLetterStore {
+	return &InMemoryDeadLetterStore{deadLetters: make(map[string]*DeadLetter)}
+}
+
+func (s *InMemoryDeadLetterStore) Save(ctx context.Context, deadLetter *DeadLetter) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.deadLetters[deadLetter.Id] = deadLetter
+
+	return nil
+}
+
+func (s *InMemoryDeadLetterStore) Find(ctx context.Context, id string) (*DeadLetter, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deadLetter, ok := s.deadLetters[id]
+	if !ok {
+		return nil, NewDeadLetterNotFound(id)
+	}
+
+	return deadLetter, nil
+}
+
+func (s *InMemoryDeadLetterStore) FindAll(ctx context.Context) ([]*DeadLetter, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deadLetters := make([]*DeadLetter, 0, len(s.deadLetters))
+	for _, deadLetter := range s.deadLetters {
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].FailedAt.Before(deadLetters[j].FailedAt)
+	})
+
+	return deadLetters, nil
+}
+
+func (s *InMemoryDeadLetterStore) Delete(ctx context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.deadLetters, id)
+
+	return nil
+}
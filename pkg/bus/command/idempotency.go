@@ -0,0 +1,250 @@
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"sync"
+	"time"
+)
+
+// IdempotencyMiddlewareName is the name the idempotency middleware is expected to be used with.
+const IdempotencyMiddlewareName = "idempotency"
+
+const idempotencyPollInterval = 50 * time.Millisecond
+
+// DefaultIdempotencyClaimTTL is used when IdempotencyMiddleware is given no claim TTL, a claim that
+// never expires would lock the key forever if its dispatcher crashed, one expiring right away would not
+// stop any duplicate.
+const DefaultIdempotencyClaimTTL = time.Minute
+
+// IdempotentCommand is a command dispatched at most once per idempotency key found in the context,
+// duplicates are skipped once it succeeds and wait for it while it runs.
+type IdempotentCommand interface {
+	// IdempotencyOwner identifies who dispatches the command when nobody is authenticated, such as on sign
+	// up, keys only need to be unique per owner.
+	IdempotencyOwner() string
+}
+
+// IdempotencyPayloader is an IdempotentCommand telling which of its fields make the request a duplicate,
+// for commands carrying values generated per dispatch, such as ids. Other commands are compared whole.
+type IdempotencyPayloader interface {
+	IdempotencyPayload() any
+}
+
+// IdempotencyOwnerFunc returns who ctx is dispatched on behalf of, empty when nobody is authenticated.
+type IdempotencyOwnerFunc func(ctx context.Context) string
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key of the commands dispatched with it.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFrom returns the idempotency key carried by ctx, if any.
+func IdempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyInFlight  IdempotencyStatus = "in_flight"
+	IdempotencyCompleted IdempotencyStatus = "completed"
+)
+
+// IdempotencyClaim is the state of a claimed key, Fingerprint identifies the payload it was claimed with.
+type IdempotencyClaim struct {
+	Status      IdempotencyStatus
+	Fingerprint string
+}
+
+type IdempotencyStore interface {
+	// Reserve claims key for ttl on behalf of the payload identified by fingerprint, when it is already
+	// claimed it returns false and the claim.
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (bool, IdempotencyClaim, error)
+	// Complete records the command under key succeeded, duplicates are skipped for ttl.
+	Complete(ctx context.Context, key string, fingerprint string, ttl time.Duration) error
+	// Release frees key so the command can be dispatched again.
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyConflict struct {
+	message string
+	key     string
+}
+
+func (i IdempotencyConflict) Error() string {
+	return i.message
+}
+
+func NewIdempotencyConflict(key string) IdempotencyConflict {
+	return IdempotencyConflict{message: "Command with the same idempotency key still in flight", key: key}
+}
+
+// IdempotencyKeyReused is an idempotency key dispatched again with a different payload.
+type IdempotencyKeyReused struct {
+	message string
+	key     string
+}
+
+func (i IdempotencyKeyReused) Error() string {
+	return i.message
+}
+
+func (i IdempotencyKeyReused) Kind() bus.ErrorKind {
+	return bus.KindValidation
+}
+
+func NewIdempotencyKeyReused(key string) IdempotencyKeyReused {
+	return IdempotencyKeyReused{message: "Idempotency key already used with a different payload", key: key}
+}
+
+// IdempotencyMiddleware runs IdempotentCommand once per (owner, idempotency key, command name). The owner
+// is the one owner returns for ctx, falling back to IdempotencyOwner for anonymous dispatches. The first
+// dispatch claims the key for claimTTL, which should outlast the command, a duplicate dispatched meanwhile
+// waits up to claimTTL for its outcome, failing with IdempotencyConflict afterwards. A key dispatched again
+// with another payload fails with IdempotencyKeyReused. Failures are not stored, the command may be
+// dispatched again with the same key.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration, claimTTL time.Duration, owner IdempotencyOwnerFunc) Middleware {
+	if claimTTL <= 0 {
+		claimTTL = DefaultIdempotencyClaimTTL
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, command bus.Dto) error {
+			idempotent, ok := command.(IdempotentCommand)
+			key := IdempotencyKeyFrom(ctx)
+			if !ok || key == "" {
+				return next(ctx, command)
+			}
+			key = idempotencyOwner(ctx, idempotent, owner) + ":" + key + ":" + bus.NameOf(command)
+
+			fingerprint, err := idempotencyFingerprint(idempotent)
+			if err != nil {
+				return err
+			}
+
+			deadline := time.Now().Add(claimTTL)
+			for {
+				// In flight claims expire after claimTTL, so a crashed dispatcher does not lock the key until ttl.
+				reserved, claim, err := store.Reserve(ctx, key, fingerprint, claimTTL)
+				if err != nil {
+					return err
+				}
+				if reserved {
+					break
+				}
+				if claim.Fingerprint != "" && claim.Fingerprint != fingerprint {
+					return NewIdempotencyKeyReused(key)
+				}
+				if claim.Status == IdempotencyCompleted {
+					return nil
+				}
+				if time.Now().After(deadline) {
+					return NewIdempotencyConflict(key)
+				}
+
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(idempotencyPollInterval):
+				}
+			}
+
+			if err := next(ctx, command); err != nil {
+				if releaseErr := store.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+					return errors.Join(err, releaseErr)
+				}
+				return err
+			}
+
+			return store.Complete(context.WithoutCancel(ctx), key, fingerprint, ttl)
+		}
+	}
+}
+
+func idempotencyOwner(ctx context.Context, command IdempotentCommand, owner IdempotencyOwnerFunc) string {
+	if owner != nil {
+		if principal := owner(ctx); principal != "" {
+			return "principal:" + principal
+		}
+	}
+
+	return "anonymous:" + command.IdempotencyOwner()
+}
+
+func idempotencyFingerprint(command IdempotentCommand) (string, error) {
+	var payload any = command
+	if payloader, ok := command.(IdempotencyPayloader); ok {
+		payload = payloader.IdempotencyPayload()
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type idempotencyRecord struct {
+	claim     IdempotencyClaim
+	expiresAt time.Time
+}
+
+// InMemoryIdempotencyStore is an IdempotencyStore that does not survive restarts nor is shared between
+// instances, meant for tests and local runs.
+type InMemoryIdempotencyStore struct {
+	records map[string]idempotencyRecord
+	lock    sync.Mutex
+}
+
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{records: make(map[string]idempotencyRecord)}
+}
+
+func (s *InMemoryIdempotencyStore) Reserve(
+	ctx context.Context,
+	key string,
+	fingerprint string,
+	ttl time.Duration,
+) (bool, IdempotencyClaim, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if record, ok := s.records[key]; ok && time.Now().Before(record.expiresAt) {
+		return false, record.claim, nil
+	}
+	claim := IdempotencyClaim{Status: IdempotencyInFlight, Fingerprint: fingerprint}
+	s.records[key] = idempotencyRecord{claim: claim, expiresAt: time.Now().Add(ttl)}
+
+	return true, claim, nil
+}
+
+func (s *InMemoryIdempotencyStore) Complete(ctx context.Context, key string, fingerprint string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.records[key] = idempotencyRecord{
+		claim:     IdempotencyClaim{Status: IdempotencyCompleted, Fingerprint: fingerprint},
+		expiresAt: time.Now().Add(ttl),
+	}
+
+	return nil
+}
+
+func (s *InMemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package command_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type idempotentCommand struct {
+	Owner string
+	Body  string
+}
+
+func (c idempotentCommand) IdempotencyOwner() string {
+	return c.Owner
+}
+
+type slowCountingHandler struct {
+	lock  sync.Mutex
+	calls int
+	err   error
+}
+
+func (h *slowCountingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	time.Sleep(20 * time.Millisecond)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.calls++
+
+	return h.err
+}
+
+func (h *slowCountingHandler) Calls() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.calls
+}
+
+type ownerContextKey struct{}
+
+func ownerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerContextKey{}).(string)
+	return owner
+}
+
+func newIdempotentBus(t *testing.T, store command.IdempotencyStore, handler command.CommandHandler) *command.CommandBus {
+	return newIdempotentBusWithClaimTTL(t, store, handler, time.Second)
+}
+
+func newIdempotentBusWithClaimTTL(
+	t *testing.T,
+	store command.IdempotencyStore,
+	handler command.CommandHandler,
+	claimTTL time.Duration,
+) *command.CommandBus {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	cb.Use(command.IdempotencyMiddlewareName, command.IdempotencyMiddleware(store, time.Minute, claimTTL, ownerFromContext))
+	require.NoError(t, cb.RegisterCommand(&idempotentCommand{}, handler))
+
+	return cb
+}
+
+func TestIdempotencyMiddleware_SkipsDuplicates(t *testing.T) {
+	handler := &slowCountingHandler{}
+	cb := newIdempotentBus(t, command.NewInMemoryIdempotencyStore(), handler)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+
+	require.NoError(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john"}))
+	require.NoError(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john"}))
+	require.NoError(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "jane"}))
+
+	assert.Equal(t, 2, handler.Calls())
+}
+
+func TestIdempotencyMiddleware_FailsOnKeysReusedWithAnotherPayload(t *testing.T) {
+	handler := &slowCountingHandler{}
+	cb := newIdempotentBus(t, command.NewInMemoryIdempotencyStore(), handler)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+
+	require.NoError(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john", Body: "first"}))
+	err := cb.Dispatch(ctx, &idempotentCommand{Owner: "john", Body: "second"})
+
+	assert.IsType(t, command.IdempotencyKeyReused{}, err)
+	assert.Equal(t, 1, handler.Calls())
+}
+
+func TestIdempotencyMiddleware_ScopesKeysToTheOwnerInContext(t *testing.T) {
+	handler := &slowCountingHandler{}
+	cb := newIdempotentBus(t, command.NewInMemoryIdempotencyStore(), handler)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+	john := context.WithValue(ctx, ownerContextKey{}, "john-id")
+	jane := context.WithValue(ctx, ownerContextKey{}, "jane-id")
+
+	// The owner the command claims is ignored once someone is authenticated.
+	require.NoError(t, cb.Dispatch(john, &idempotentCommand{Owner: "jane"}))
+	require.NoError(t, cb.Dispatch(jane, &idempotentCommand{Owner: "jane"}))
+	require.NoError(t, cb.Dispatch(john, &idempotentCommand{Owner: "jane"}))
+
+	assert.Equal(t, 2, handler.Calls())
+}
+
+func TestIdempotencyMiddleware_WaitsForInFlightDuplicates(t *testing.T) {
+	handler := &slowCountingHandler{}
+	cb := newIdempotentBus(t, command.NewInMemoryIdempotencyStore(), handler)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = cb.Dispatch(ctx, &idempotentCommand{Owner: "john"})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, handler.Calls())
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestIdempotencyMiddleware_ZeroClaimTTLStillStopsInFlightDuplicates(t *testing.T) {
+	handler := &slowCountingHandler{}
+	cb := newIdempotentBusWithClaimTTL(t, command.NewInMemoryIdempotencyStore(), handler, 0)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = cb.Dispatch(ctx, &idempotentCommand{Owner: "john"})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, handler.Calls())
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestIdempotencyMiddleware_DoesNotStoreFailures(t *testing.T) {
+	handler := &slowCountingHandler{err: errors.New("boom")}
+	cb := newIdempotentBus(t, command.NewInMemoryIdempotencyStore(), handler)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+
+	assert.Error(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john"}))
+	assert.Error(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john"}))
+
+	assert.Equal(t, 2, handler.Calls())
+}
+
+func TestRedisIdempotencyStore_SkipsDuplicates(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := command.NewRedisIdempotencyStore(redis.NewClient(&redis.Options{Addr: server.Addr()}), "idempotency:")
+	handler := &slowCountingHandler{}
+	cb := newIdempotentBus(t, store, handler)
+	ctx := command.WithIdempotencyKey(context.Background(), "key")
+
+	require.NoError(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john"}))
+	require.NoError(t, cb.Dispatch(ctx, &idempotentCommand{Owner: "john"}))
+	err := cb.Dispatch(ctx, &idempotentCommand{Owner: "john", Body: "another"})
+
+	assert.IsType(t, command.IdempotencyKeyReused{}, err)
+	assert.Equal(t, 1, handler.Calls())
+}
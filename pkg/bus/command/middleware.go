@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+)
+
+// HandlerFunc is the signature every middleware wraps, ending in the CommandHandler itself.
+type HandlerFunc func(ctx context.Context, command bus.Dto) error
+
+// Middleware decorates the next HandlerFunc in the chain.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type namedMiddleware struct {
+	name       string
+	middleware Middleware
+}
+
+// Use appends a middleware to the bus. Middlewares run in registration order,
+// the first one registered being the outermost, for both sync and async dispatch.
+// Commands can opt out of it by returning its name from bus.MiddlewareOptOut.
+func (bus *CommandBus) Use(name string, middleware Middleware) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.middlewares = append(bus.middlewares, namedMiddleware{name: name, middleware: middleware})
+}
+
+func (bus *CommandBus) chain(handler CommandHandler, command bus.Dto) HandlerFunc {
+	bus.lock.Lock()
+	middlewares := make([]namedMiddleware, len(bus.middlewares))
+	copy(middlewares, bus.middlewares)
+	bus.lock.Unlock()
+
+	var next HandlerFunc = handler.Handle
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if skipsMiddleware(command, middlewares[i].name) {
+			continue
+		}
+		next = middlewares[i].middleware(next)
+	}
+
+	return next
+}
+
+func skipsMiddleware(command bus.Dto, name string) bool {
+	return bus.SkipsMiddleware(command, name)
+}
+
+// ValidationMiddleware rejects commands failing bus.Validate before they reach their handler.
+func ValidationMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, command bus.Dto) error {
+			if err := bus.Validate(command); err != nil {
+				return err
+			}
+
+			return next(ctx, command)
+		}
+	}
+}
+
+// TransactionMiddlewareName is the name the transaction middleware is expected to be used with.
+const TransactionMiddlewareName = "transaction"
+
+// TransactionMiddleware runs every command in a unit of work of tm, committed once its handler succeeds.
+func TransactionMiddleware(tm transaction.Manager) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, command bus.Dto) error {
+			return tm.Do(ctx, func(ctx context.Context) error {
+				return next(ctx, command)
+			})
+		}
+	}
+}
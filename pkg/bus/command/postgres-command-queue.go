@@ -0,0 +1,241 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+type commandJob struct {
+	ID          string    `gorm:"type:uuid;primaryKey"`
+	CommandName string    `gorm:"type:varchar(255)"`
+	Payload     string    `gorm:"type:jsonb"`
+	Errors      string    `gorm:"type:jsonb;default:'[]'"`
+	Attempts    int       `gorm:"type:integer;default:0"`
+	RunAt       time.Time `gorm:"index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+func (commandJob) TableName() string {
+	return "command_jobs"
+}
+
+// PostgresCommandQueue is a Bus transport that persists async commands in a jobs table,
+// so they survive restarts, and runs them on a pool of workers. Sync dispatch and
+// handler registration are delegated to the wrapped CommandBus, whose registry is used
+// to (de)serialize the commands and whose retry policies and dead letter store apply to jobs.
+type PostgresCommandQueue struct {
+	DB           *gorm.DB
+	cb           *CommandBus
+	l            shared_image_infrastructure.Logger
+	workers      int
+	pollInterval time.Duration
+	wg           sync.WaitGroup
+}
+
+func NewPostgresCommandQueue(
+	db *gorm.DB,
+	cb *CommandBus,
+	l shared_image_infrastructure.Logger,
+	workers int,
+	pollInterval time.Duration,
+) (*PostgresCommandQueue, error) {
+	if err := db.AutoMigrate(&commandJob{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresCommandQueue{DB: db, cb: cb, l: l, workers: workers, pollInterval: pollInterval}, nil
+}
+
+func (q *PostgresCommandQueue) RegisterCommand(command bus.Dto, handler CommandHandler) error {
+	return q.cb.RegisterCommand(command, handler)
+}
+
+func (q *PostgresCommandQueue) Dispatch(ctx context.Context, command bus.Dto) error {
+	return q.cb.Dispatch(ctx, command)
+}
+
+// DispatchAsync stores the command as a job, it returns once the job is persisted.
+func (q *PostgresCommandQueue) DispatchAsync(ctx context.Context, command bus.Dto) error {
+	return q.DispatchAt(ctx, command, time.Now())
+}
+
+// DispatchAt stores the command as a job run once at is reached. Within a unit of work the job is
+// only stored if it commits.
+func (q *PostgresCommandQueue) DispatchAt(ctx context.Context, command bus.Dto, at time.Time) error {
+	commandName, payload, err := q.cb.Registry().Encode(command)
+	if err != nil {
+		return err
+	}
+
+	result := transaction.DB(ctx, q.DB).Create(&commandJob{
+		ID:          uuid.NewString(),
+		CommandName: commandName,
+		Payload:     string(payload),
+		Errors:      "[]",
+		RunAt:       at,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to enqueue command: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (q *PostgresCommandQueue) DispatchAfter(ctx context.Context, command bus.Dto, delay time.Duration) error {
+	return q.DispatchAt(ctx, command, time.Now().Add(delay))
+}
+
+func (q *PostgresCommandQueue) ProcessFailed(ctx context.Context) {
+	q.cb.ProcessFailed(ctx)
+}
+
+// Start launches the workers, they stop picking jobs once ctx is done. Use Wait to drain them.
+func (q *PostgresCommandQueue) Start(ctx context.Context) {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+}
+
+// Wait blocks until every worker finished its in-flight job or ctx is done.
+func (q *PostgresCommandQueue) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *PostgresCommandQueue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	for {
+		// In-flight jobs are not cancelled on shutdown, they are drained instead.
+		processed, err := q.processNext(context.WithoutCancel(ctx))
+		if err != nil {
+			q.l.Error(ctx, "error processing command job", map[string]interface{}{"error": err.Error()})
+		}
+
+		if processed && err == nil && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
+
+// processNext locks the next due job and runs it inside the same transaction,
+// so a crashed worker releases the job back to the queue.
+func (q *PostgresCommandQueue) processNext(ctx context.Context) (bool, error) {
+	processed := false
+
+	err := q.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var jobs []commandJob
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("run_at <= ?", time.Now()).
+			Order("run_at").
+			Limit(1).
+			Find(&jobs)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		processed = true
+		return q.run(ctx, tx, &jobs[0])
+	})
+
+	return processed, err
+}
+
+func (q *PostgresCommandQueue) run(ctx context.Context, tx *gorm.DB, job *commandJob) error {
+	var errs []string
+	if err := json.Unmarshal([]byte(job.Errors), &errs); err != nil {
+		return fmt.Errorf("failed to decode job errors: %w", err)
+	}
+
+	command, err := q.cb.Registry().Decode(job.CommandName, []byte(job.Payload))
+	if err != nil {
+		return q.deadLetter(ctx, tx, job, append(errs, err.Error()))
+	}
+
+	if job.Attempts == 0 {
+		err = q.cb.Dispatch(ctx, command)
+	} else {
+		err = q.cb.redispatch(ctx, command)
+	}
+	job.Attempts++
+	if err == nil {
+		return tx.Delete(job).Error
+	}
+
+	errs = append(errs, err.Error())
+	q.l.Warn(ctx, "failing processing command", map[string]interface{}{
+		"error":   err.Error(),
+		"command": job.CommandName,
+		"attempt": job.Attempts,
+	})
+
+	policy := q.retryPolicyFor(command)
+	if job.Attempts >= policy.MaxAttempts || !policy.IsRetryable(err) {
+		return q.deadLetter(ctx, tx, job, errs)
+	}
+
+	encodedErrs, err := json.Marshal(errs)
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(job).Updates(map[string]interface{}{
+		"attempts": job.Attempts,
+		"errors":   string(encodedErrs),
+		"run_at":   time.Now().Add(policy.Backoff(job.Attempts)),
+	}).Error
+}
+
+func (q *PostgresCommandQueue) retryPolicyFor(command bus.Dto) RetryPolicy {
+	commandName, err := commandName(command)
+	if err != nil {
+		return q.cb.retryPolicyFor("")
+	}
+
+	return q.cb.retryPolicyFor(*commandName)
+}
+
+func (q *PostgresCommandQueue) deadLetter(ctx context.Context, tx *gorm.DB, job *commandJob, errs []string) error {
+	err := q.cb.storeDeadLetter(ctx, &DeadLetter{
+		Id:          job.ID,
+		CommandName: job.CommandName,
+		Payload:     json.RawMessage(job.Payload),
+		Errors:      errs,
+		Attempts:    job.Attempts,
+		FailedAt:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Delete(job).Error
+}
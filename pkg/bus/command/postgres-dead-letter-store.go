@@ -0,0 +1,117 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+type deadLetterRecord struct {
+	ID          string    `gorm:"type:uuid;primaryKey"`
+	CommandName string    `gorm:"type:varchar(255);index"`
+	Payload     string    `gorm:"type:jsonb"`
+	Errors      string    `gorm:"type:jsonb"`
+	Attempts    int       `gorm:"type:integer"`
+	FailedAt    time.Time `gorm:"index"`
+}
+
+func (deadLetterRecord) TableName() string {
+	return "command_dead_letters"
+}
+
+// PostgresDeadLetterStore is a DeadLetterStore using Gorm, commands are kept as their JSON payload.
+type PostgresDeadLetterStore struct {
+	DB *gorm.DB
+}
+
+func NewPostgresDeadLetterStore(db *gorm.DB) (*PostgresDeadLetterStore, error) {
+	if err := db.AutoMigrate(&deadLetterRecord{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresDeadLetterStore{DB: db}, nil
+}
+
+func (s *PostgresDeadLetterStore) Save(ctx context.Context, deadLetter *DeadLetter) error {
+	payload := deadLetter.Payload
+	if payload == nil {
+		var err error
+		if payload, err = json.Marshal(deadLetter.Command); err != nil {
+			return fmt.Errorf("failed to encode dead letter command: %w", err)
+		}
+	}
+
+	errs, err := json.Marshal(deadLetter.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter errors: %w", err)
+	}
+
+	result := s.DB.WithContext(ctx).Save(&deadLetterRecord{
+		ID:          deadLetter.Id,
+		CommandName: deadLetter.CommandName,
+		Payload:     string(payload),
+		Errors:      string(errs),
+		Attempts:    deadLetter.Attempts,
+		FailedAt:    deadLetter.FailedAt,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save dead letter: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (s *PostgresDeadLetterStore) Find(ctx context.Context, id string) (*DeadLetter, error) {
+	var record deadLetterRecord
+	result := s.DB.WithContext(ctx).First(&record, "id = ?", id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, NewDeadLetterNotFound(id)
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return record.toDeadLetter()
+}
+
+func (s *PostgresDeadLetterStore) FindAll(ctx context.Context) ([]*DeadLetter, error) {
+	var records []deadLetterRecord
+	result := s.DB.WithContext(ctx).Order("failed_at").Find(&records)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	deadLetters := make([]*DeadLetter, len(records))
+	for i, record := range records {
+		deadLetter, err := record.toDeadLetter()
+		if err != nil {
+			return nil, err
+		}
+		deadLetters[i] = deadLetter
+	}
+
+	return deadLetters, nil
+}
+
+func (s *PostgresDeadLetterStore) Delete(ctx context.Context, id string) error {
+	return s.DB.WithContext(ctx).Delete(&deadLetterRecord{}, "id = ?", id).Error
+}
+
+func (r deadLetterRecord) toDeadLetter() (*DeadLetter, error) {
+	var errs []string
+	if err := json.Unmarshal([]byte(r.Errors), &errs); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter errors: %w", err)
+	}
+
+	return &DeadLetter{
+		Id:          r.ID,
+		CommandName: r.CommandName,
+		Payload:     json.RawMessage(r.Payload),
+		Errors:      errs,
+		Attempts:    r.Attempts,
+		FailedAt:    r.FailedAt,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+type idempotencyKeyRecord struct {
+	Key         string    `gorm:"type:varchar(512);primaryKey"`
+	Status      string    `gorm:"type:varchar(32)"`
+	Fingerprint string    `gorm:"type:varchar(64)"`
+	ExpiresAt   time.Time `gorm:"index"`
+}
+
+func (idempotencyKeyRecord) TableName() string {
+	return "command_idempotency_keys"
+}
+
+// PostgresIdempotencyStore is an IdempotencyStore using Gorm, shared by every instance using the database.
+type PostgresIdempotencyStore struct {
+	DB *gorm.DB
+}
+
+func NewPostgresIdempotencyStore(db *gorm.DB) (*PostgresIdempotencyStore, error) {
+	if err := db.AutoMigrate(&idempotencyKeyRecord{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresIdempotencyStore{DB: db}, nil
+}
+
+func (s *PostgresIdempotencyStore) Reserve(
+	ctx context.Context,
+	key string,
+	fingerprint string,
+	ttl time.Duration,
+) (bool, IdempotencyClaim, error) {
+	now := time.Now()
+
+	// Expired claims are taken over, live ones are left untouched.
+	result := s.DB.WithContext(ctx).Exec(
+		`INSERT INTO command_idempotency_keys (key, status, fingerprint, expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE
+		SET status = EXCLUDED.status, fingerprint = EXCLUDED.fingerprint, expires_at = EXCLUDED.expires_at
+		WHERE command_idempotency_keys.expires_at < ?`,
+		key, string(IdempotencyInFlight), fingerprint, now.Add(ttl), now,
+	)
+	if result.Error != nil {
+		return false, IdempotencyClaim{}, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, IdempotencyClaim{Status: IdempotencyInFlight, Fingerprint: fingerprint}, nil
+	}
+
+	var record idempotencyKeyRecord
+	err := s.DB.WithContext(ctx).Where("key = ?", key).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Released meanwhile, the caller tries again.
+		return false, IdempotencyClaim{}, nil
+	}
+	if err != nil {
+		return false, IdempotencyClaim{}, err
+	}
+
+	return false, IdempotencyClaim{Status: IdempotencyStatus(record.Status), Fingerprint: record.Fingerprint}, nil
+}
+
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, key string, fingerprint string, ttl time.Duration) error {
+	return s.DB.WithContext(ctx).
+		Model(&idempotencyKeyRecord{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{
+			"status":      string(IdempotencyCompleted),
+			"fingerprint": fingerprint,
+			"expires_at":  time.Now().Add(ttl),
+		}).
+		Error
+}
+
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.DB.WithContext(ctx).Where("key = ?", key).Delete(&idempotencyKeyRecord{}).Error
+}
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"time"
+)
+
+// RedisIdempotencyStore is an IdempotencyStore keeping the claims as expiring Redis keys, valued
+// "<status>:<fingerprint>".
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisIdempotencyStore(client *redis.Client, prefix string) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+func (s *RedisIdempotencyStore) Reserve(
+	ctx context.Context,
+	key string,
+	fingerprint string,
+	ttl time.Duration,
+) (bool, IdempotencyClaim, error) {
+	claim := IdempotencyClaim{Status: IdempotencyInFlight, Fingerprint: fingerprint}
+	reserved, err := s.client.SetNX(ctx, s.prefix+key, encodeIdempotencyClaim(claim), ttl).Result()
+	if err != nil {
+		return false, IdempotencyClaim{}, err
+	}
+	if reserved {
+		return true, claim, nil
+	}
+
+	value, err := s.client.Get(ctx, s.prefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		// Released or expired meanwhile, the caller tries again.
+		return false, IdempotencyClaim{}, nil
+	}
+	if err != nil {
+		return false, IdempotencyClaim{}, err
+	}
+
+	return false, decodeIdempotencyClaim(value), nil
+}
+
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, fingerprint string, ttl time.Duration) error {
+	claim := IdempotencyClaim{Status: IdempotencyCompleted, Fingerprint: fingerprint}
+
+	return s.client.Set(ctx, s.prefix+key, encodeIdempotencyClaim(claim), ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+func encodeIdempotencyClaim(claim IdempotencyClaim) string {
+	return string(claim.Status) + ":" + claim.Fingerprint
+}
+
+func decodeIdempotencyClaim(value string) IdempotencyClaim {
+	status, fingerprint, _ := strings.Cut(value, ":")
+
+	return IdempotencyClaim{Status: IdempotencyStatus(status), Fingerprint: fingerprint}
+}
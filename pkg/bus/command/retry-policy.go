@@ -0,0 +1,78 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides how many times and how often a failed async command is retried
+// before it is moved to the DeadLetterStore.
+type RetryPolicy struct {
+	// MaxAttempts counts the first execution, so 1 means no retries at all.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction (0 to 1) of each backoff that is randomized.
+	Jitter float64
+	// Retryable classifies handler errors, when nil every error is retried but NonRetryable ones and
+	// the domain and validation ones.
+	Retryable func(err error) bool
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Backoff returns the delay before the given retry, attempt being the number of executions so far.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		backoff -= backoff * p.Jitter * rand.Float64()
+	}
+
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	if kind := bus.KindOf(err); kind == bus.KindDomain || kind == bus.KindValidation || kind == bus.KindForbidden {
+		return false
+	}
+
+	return !errors.As(err, new(NonRetryable)) && !errors.Is(err, context.Canceled)
+}
+
+// NonRetryable wraps handler errors that would fail again no matter how many times the command is retried.
+type NonRetryable struct {
+	err error
+}
+
+func NewNonRetryable(err error) NonRetryable {
+	return NonRetryable{err: err}
+}
+
+func (n NonRetryable) Error() string {
+	return n.err.Error()
+}
+
+func (n NonRetryable) Unwrap() error {
+	return n.err
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus"
+)
+
+// TypedCommandHandler handles commands of type C, the bus does the type assertion for it.
+type TypedCommandHandler[C bus.Dto] interface {
+	Handle(ctx context.Context, command C) error
+}
+
+type typedCommandHandler[C bus.Dto] struct {
+	handler TypedCommandHandler[C]
+}
+
+func (t typedCommandHandler[C]) Handle(ctx context.Context, command bus.Dto) error {
+	c, ok := command.(C)
+	if !ok {
+		return bus.NewInvalidDto(fmt.Sprintf("command %s is %T, expected %T", bus.NameOf(command), command, c))
+	}
+
+	return t.handler.Handle(ctx, c)
+}
+
+// Unwrap returns the adapted handler, so introspection reports its type.
+func (t typedCommandHandler[C]) Unwrap() interface{} {
+	return t.handler
+}
+
+// Handler adapts a TypedCommandHandler to a CommandHandler.
+func Handler[C bus.Dto](handler TypedCommandHandler[C]) CommandHandler {
+	return typedCommandHandler[C]{handler: handler}
+}
+
+// Register registers handler for the commands of type C.
+func Register[C bus.Dto](b Bus, handler TypedCommandHandler[C]) error {
+	return b.RegisterCommand(bus.New[C](), Handler(handler))
+}
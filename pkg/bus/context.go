@@ -0,0 +1,81 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/mik3lon/starter-template/pkg/transaction"
+	"time"
+)
+
+// Detach returns a context keeping the values of ctx but not its cancellation nor deadline, for
+// work outliving the request that started it. Gin recycles its contexts once the request is
+// served, so they are copied first, and the unit of work of ctx is dropped as it may be finished.
+func Detach(ctx context.Context) context.Context {
+	if g, ok := ctx.(*gin.Context); ok {
+		ctx = g.Copy()
+	}
+
+	return transaction.Detach(context.WithoutCancel(ctx))
+}
+
+type HandlerTimeout struct {
+	message string
+	name    string
+	timeout time.Duration
+}
+
+func (h HandlerTimeout) Error() string {
+	return h.message
+}
+
+func (h HandlerTimeout) Kind() ErrorKind {
+	return KindTransient
+}
+
+func NewHandlerTimeout(name string, timeout time.Duration) HandlerTimeout {
+	return HandlerTimeout{
+		message: fmt.Sprintf("handler of %s timed out after %s", name, timeout),
+		name:    name,
+		timeout: timeout,
+	}
+}
+
+// WithTimeout runs handle with a context cancelled after timeout, returning a HandlerTimeout once it
+// expires even if handle ignores the cancellation and keeps running. A zero timeout runs handle as is.
+func WithTimeout[T any](ctx context.Context, name string, timeout time.Duration, handle func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return handle(ctx)
+	}
+
+	// handle may outlive this call, it must not see a gin context recycled by then.
+	if g, ok := ctx.(*gin.Context); ok {
+		ctx = g.Copy()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := handle(ctx)
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() == context.DeadlineExceeded {
+			return r.value, NewHandlerTimeout(name, timeout)
+		}
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		if ctx.Err() == context.DeadlineExceeded {
+			return zero, NewHandlerTimeout(name, timeout)
+		}
+		return zero, ctx.Err()
+	}
+}
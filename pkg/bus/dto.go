@@ -1,6 +1,14 @@
 package bus
 
-type Dto interface {
+import "reflect"
+
+// Dto is any message travelling through a bus, commands, queries and events are usually pointers to
+// structs. Its name is derived from its Go type unless it implements Identifiable.
+type Dto interface{}
+
+// Identifiable lets a Dto override the name derived from its type, e.g. to keep the name of messages
+// already persisted when the type is renamed or moved.
+type Identifiable interface {
 	Id() string
 }
 
@@ -15,3 +23,41 @@ func NewInvalidDto(message string) *InvalidDto {
 func (i InvalidDto) Error() string {
 	return i.message
 }
+
+func (i InvalidDto) Kind() ErrorKind {
+	return KindValidation
+}
+
+// NameOf returns the name identifying dto in the buses: its Id when it is Identifiable, otherwise
+// the import path and name of its type, e.g. "github.com/acme/app/user.CreateUserCommand".
+func NameOf(dto Dto) string {
+	dtoType := reflect.TypeOf(dto)
+	if dtoType == nil {
+		return ""
+	}
+
+	if _, ok := dto.(Identifiable); ok {
+		// Id may have a value receiver, call it on an allocated value instead of a nil pointer.
+		if value := reflect.ValueOf(dto); value.Kind() == reflect.Pointer && value.IsNil() {
+			dto = reflect.New(dtoType.Elem()).Interface()
+		}
+
+		return dto.(Identifiable).Id()
+	}
+
+	for dtoType.Kind() == reflect.Pointer {
+		dtoType = dtoType.Elem()
+	}
+
+	return dtoType.PkgPath() + "." + dtoType.Name()
+}
+
+// New returns a zero value of T, allocating the struct when T is a pointer.
+func New[T Dto]() T {
+	var dto T
+	if t := reflect.TypeOf(dto); t != nil && t.Kind() == reflect.Pointer {
+		dto = reflect.New(t.Elem()).Interface().(T)
+	}
+
+	return dto
+}
@@ -0,0 +1,114 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrorKind classifies handler errors, so retries and transports decide on them without knowing
+// every error type.
+type ErrorKind string
+
+const (
+	// KindDomain is a business rule rejecting the message, it fails again if retried.
+	KindDomain ErrorKind = "domain"
+	// KindValidation is a malformed message, it fails again if retried.
+	KindValidation ErrorKind = "validation"
+	// KindForbidden is a message its sender is not allowed to send, it fails again if retried.
+	KindForbidden ErrorKind = "forbidden"
+	// KindTransient is a failure likely to go away if retried, such as timeouts or serialization conflicts.
+	KindTransient ErrorKind = "transient"
+	// KindInfrastructure is any other failure of the system handling the message.
+	KindInfrastructure ErrorKind = "infrastructure"
+)
+
+// Classified is an error telling its own kind.
+type Classified interface {
+	Kind() ErrorKind
+}
+
+// domainError is the shape of the errors of the domain packages, which do not depend on the bus.
+type domainError interface {
+	error
+	ExtraItems() map[string]interface{}
+}
+
+// sqlError is implemented by the errors of the Postgres driver.
+type sqlError interface {
+	SQLState() string
+}
+
+// KindOf classifies err, errors not recognized as any other kind are infrastructure ones.
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var classified Classified
+	if errors.As(err, &classified) {
+		return classified.Kind()
+	}
+	if errors.As(err, new(domainError)) {
+		return KindDomain
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTransient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return KindTransient
+	}
+
+	var sqlErr sqlError
+	if errors.As(err, &sqlErr) {
+		state := sqlErr.SQLState()
+		// Serialization failures, deadlocks, lost connections and server shutdowns.
+		if state == "40001" || state == "40P01" || strings.HasPrefix(state, "08") || state == "57P01" {
+			return KindTransient
+		}
+	}
+
+	return KindInfrastructure
+}
+
+// HandlerPanic is a panic recovered from a handler.
+type HandlerPanic struct {
+	message string
+	name    string
+	Value   interface{}
+	Stack   string
+}
+
+func (h HandlerPanic) Error() string {
+	return h.message
+}
+
+func (h HandlerPanic) Kind() ErrorKind {
+	return KindInfrastructure
+}
+
+func NewHandlerPanic(name string, value interface{}, stack []byte) HandlerPanic {
+	return HandlerPanic{
+		message: fmt.Sprintf("handler of %s panicked: %v", name, value),
+		name:    name,
+		Value:   value,
+		Stack:   string(stack),
+	}
+}
+
+// Recover runs handle returning its panic, if any, as a HandlerPanic. Panics only unwind the goroutine
+// raising them, so it must run in the goroutine calling the handler.
+func Recover[T any](name string, handle func() (T, error)) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewHandlerPanic(name, r, debug.Stack())
+		}
+	}()
+
+	return handle()
+}
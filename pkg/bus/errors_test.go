@@ -0,0 +1,67 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ruleBroken struct{}
+
+func (r ruleBroken) Error() string {
+	return "rule broken"
+}
+
+func (r ruleBroken) ExtraItems() map[string]interface{} {
+	return nil
+}
+
+type pgError struct {
+	code string
+}
+
+func (p pgError) Error() string {
+	return "pg error " + p.code
+}
+
+func (p pgError) SQLState() string {
+	return p.code
+}
+
+func TestKindOf(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		kind bus.ErrorKind
+	}{
+		"domain":                {err: fmt.Errorf("wrapped: %w", &ruleBroken{}), kind: bus.KindDomain},
+		"validation":            {err: bus.NewValidationError(bus.FieldError{Field: "email", Message: "is required"}), kind: bus.KindValidation},
+		"timeout":               {err: bus.NewHandlerTimeout("cmd", 0), kind: bus.KindTransient},
+		"deadline":              {err: context.DeadlineExceeded, kind: bus.KindTransient},
+		"serialization failure": {err: pgError{code: "40001"}, kind: bus.KindTransient},
+		"unique violation":      {err: pgError{code: "23505"}, kind: bus.KindInfrastructure},
+		"panic":                 {err: bus.NewHandlerPanic("cmd", "boom", nil), kind: bus.KindInfrastructure},
+		"unknown":               {err: errors.New("boom"), kind: bus.KindInfrastructure},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, bus.KindOf(tt.err))
+		})
+	}
+}
+
+func TestRecover_ReturnsPanicAsHandlerPanic(t *testing.T) {
+	_, err := bus.Recover("cmd", func() (struct{}, error) {
+		panic("boom")
+	})
+
+	var handlerPanic bus.HandlerPanic
+	require.ErrorAs(t, err, &handlerPanic)
+	assert.Equal(t, "boom", handlerPanic.Value)
+	assert.Contains(t, handlerPanic.Stack, "errors_test.go")
+}
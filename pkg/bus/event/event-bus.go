@@ -0,0 +1,135 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"sync"
+)
+
+type Bus interface {
+	Subscribe(event bus.Dto, handler EventHandler) error
+	Publish(ctx context.Context, events ...bus.Dto) error
+	PublishAsync(ctx context.Context, events ...bus.Dto) error
+}
+
+// EventBus delivers every published event to all of its subscribers, unlike commands
+// and queries an event may have any number of handlers, including none.
+type EventBus struct {
+	subscribers map[string][]EventHandler
+	registry    *bus.Registry
+	lock        sync.RWMutex
+	l           shared_image_infrastructure.Logger
+}
+
+func InitEventBus(l shared_image_infrastructure.Logger) *EventBus {
+	return &EventBus{
+		subscribers: make(map[string][]EventHandler, 0),
+		registry:    bus.NewRegistry(),
+		lock:        sync.RWMutex{},
+		l:           l,
+	}
+}
+
+func (bus *EventBus) Subscribe(event bus.Dto, handler EventHandler) error {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	if err := bus.registry.Register(event); err != nil {
+		return err
+	}
+
+	bus.subscribers[eventName(event)] = append(bus.subscribers[eventName(event)], handler)
+
+	return nil
+}
+
+// RegisterEvent makes events known to the bus without subscribing to them, so
+// transports like the outbox relay can rebuild them.
+func (bus *EventBus) RegisterEvent(events ...bus.Dto) error {
+	for _, event := range events {
+		if err := bus.registry.Register(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Registry exposes the registered and subscribed events so transports can (de)serialize them.
+func (bus *EventBus) Registry() *bus.Registry {
+	return bus.registry
+}
+
+// Publish runs every subscriber of every event before returning, a failing subscriber
+// does not prevent the others from running and its error is joined into the returned one.
+func (bus *EventBus) Publish(ctx context.Context, events ...bus.Dto) error {
+	var errs []error
+
+	for _, event := range events {
+		for _, handler := range bus.handlersFor(event) {
+			if err := handle(ctx, bus.l, handler, event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// PublishAsync runs every subscriber in its own goroutine with a context detached from ctx,
+// failures are only logged.
+func (bus *EventBus) PublishAsync(ctx context.Context, events ...bus.Dto) error {
+	ctx = detach(ctx)
+
+	for _, event := range events {
+		for _, handler := range bus.handlersFor(event) {
+			go func() {
+				if err := handle(ctx, bus.l, handler, event); err != nil {
+					bus.l.Error(ctx, "error handling event", map[string]interface{}{
+						"error": err.Error(),
+						"event": eventName(event),
+					})
+				}
+			}()
+		}
+	}
+
+	return nil
+}
+
+// handle runs handler recovering its panic, if any, as a bus.HandlerPanic.
+func handle(ctx context.Context, l shared_image_infrastructure.Logger, handler EventHandler, event bus.Dto) error {
+	name := eventName(event)
+	_, err := bus.Recover(name, func() (struct{}, error) {
+		return struct{}{}, handler.Handle(ctx, event)
+	})
+
+	var handlerPanic bus.HandlerPanic
+	if errors.As(err, &handlerPanic) {
+		l.Error(ctx, "event handler panicked", map[string]interface{}{
+			"event": name,
+			"panic": fmt.Sprint(handlerPanic.Value),
+			"stack": handlerPanic.Stack,
+		})
+	}
+
+	return err
+}
+
+func (bus *EventBus) handlersFor(event bus.Dto) []EventHandler {
+	bus.lock.RLock()
+	defer bus.lock.RUnlock()
+
+	return bus.subscribers[eventName(event)]
+}
+
+func eventName(event bus.Dto) string {
+	return bus.NameOf(event)
+}
+
+func detach(ctx context.Context) context.Context {
+	return bus.Detach(ctx)
+}
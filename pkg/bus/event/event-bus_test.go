@@ -0,0 +1,75 @@
+package event_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/event"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct{}
+
+func (e testEvent) Id() string {
+	return "test-event"
+}
+
+type recordingHandler struct {
+	lock   sync.Mutex
+	events []bus.Dto
+	err    error
+	wg     *sync.WaitGroup
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, e bus.Dto) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.events = append(h.events, e)
+	if h.wg != nil {
+		h.wg.Done()
+	}
+	return h.err
+}
+
+func TestEventBus_Publish_DeliversToEverySubscriber(t *testing.T) {
+	eb := event.InitEventBus(shared_image_infrastructure.NewZerologAdapter())
+	first := &recordingHandler{err: errors.New("first failed")}
+	second := &recordingHandler{}
+	require.NoError(t, eb.Subscribe(&testEvent{}, first))
+	require.NoError(t, eb.Subscribe(&testEvent{}, second))
+
+	err := eb.Publish(context.Background(), &testEvent{})
+
+	assert.EqualError(t, err, "first failed")
+	assert.Len(t, first.events, 1)
+	assert.Len(t, second.events, 1)
+}
+
+func TestEventBus_Publish_WithoutSubscribers(t *testing.T) {
+	eb := event.InitEventBus(shared_image_infrastructure.NewZerologAdapter())
+
+	err := eb.Publish(context.Background(), &testEvent{})
+
+	assert.NoError(t, err)
+}
+
+func TestEventBus_PublishAsync_DeliversToEverySubscriber(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	eb := event.InitEventBus(shared_image_infrastructure.NewZerologAdapter())
+	first := &recordingHandler{wg: wg}
+	second := &recordingHandler{wg: wg}
+	require.NoError(t, eb.Subscribe(&testEvent{}, first))
+	require.NoError(t, eb.Subscribe(&testEvent{}, second))
+
+	err := eb.PublishAsync(context.Background(), &testEvent{})
+	wg.Wait()
+
+	assert.NoError(t, err)
+}
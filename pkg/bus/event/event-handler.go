@@ -0,0 +1,10 @@
+package event
+
+import (
+	"context"
+	"github.com/mik3lon/starter-template/pkg/bus"
+)
+
+type EventHandler interface {
+	Handle(ctx context.Context, event bus.Dto) error
+}
@@ -0,0 +1,145 @@
+package event
+
+import (
+	"context"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync/atomic"
+	"time"
+)
+
+// Publisher is where the OutboxRelay delivers events, the EventBus or an external broker.
+type Publisher interface {
+	Publish(ctx context.Context, events ...bus.Dto) error
+}
+
+// OutboxRelay publishes pending outbox messages in order. A message is only marked as
+// delivered once the publisher accepted it, so delivery is at least once. Failing messages are
+// retried following the RetryPolicy and parked as dead once it is exhausted.
+type OutboxRelay struct {
+	outbox       *PostgresOutbox
+	registry     *bus.Registry
+	publisher    Publisher
+	l            shared_image_infrastructure.Logger
+	batchSize    int
+	pollInterval time.Duration
+	retryPolicy  command.RetryPolicy
+	delivered    atomic.Uint64
+	failed       atomic.Uint64
+}
+
+func NewOutboxRelay(
+	outbox *PostgresOutbox,
+	registry *bus.Registry,
+	publisher Publisher,
+	l shared_image_infrastructure.Logger,
+	batchSize int,
+	pollInterval time.Duration,
+	retryPolicy command.RetryPolicy,
+) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:       outbox,
+		registry:     registry,
+		publisher:    publisher,
+		l:            l,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		retryPolicy:  retryPolicy,
+	}
+}
+
+// Run relays pending messages until ctx is done.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	for {
+		relayed, err := r.relayBatch(ctx)
+		if err != nil {
+			r.l.Error(ctx, "error relaying outbox messages", map[string]interface{}{"error": err.Error()})
+		}
+
+		if relayed == r.batchSize && err == nil && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// Stats returns the pending messages and lag of the outbox and the relay counters since start.
+func (r *OutboxRelay) Stats(ctx context.Context) (*OutboxStats, error) {
+	stats, err := r.outbox.pendingStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats.Delivered = r.delivered.Load()
+	stats.Failed = r.failed.Load()
+
+	return stats, nil
+}
+
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	relayed := 0
+
+	err := r.outbox.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var messages []OutboxMessage
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered_at IS NULL AND dead_at IS NULL").
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
+			Order("id").
+			Limit(r.batchSize).
+			Find(&messages)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for i := range messages {
+			delivered, err := r.relay(ctx, tx, &messages[i])
+			if err != nil {
+				return err
+			}
+			if delivered {
+				relayed++
+			}
+		}
+
+		return nil
+	})
+
+	return relayed, err
+}
+
+func (r *OutboxRelay) relay(ctx context.Context, tx *gorm.DB, message *OutboxMessage) (bool, error) {
+	event, err := r.registry.Decode(message.EventName, []byte(message.Payload))
+	if err == nil {
+		err = r.publisher.Publish(ctx, event)
+	}
+
+	if err != nil {
+		r.failed.Add(1)
+		message.Fail(err, r.retryPolicy, time.Now())
+		r.l.Warn(ctx, "failing relaying outbox message", map[string]interface{}{
+			"error":      err.Error(),
+			"event":      message.EventName,
+			"message_id": message.ID,
+			"attempt":    message.Attempts,
+			"dead":       message.DeadAt != nil,
+		})
+
+		return false, tx.Model(message).Updates(map[string]interface{}{
+			"attempts":        message.Attempts,
+			"last_error":      message.LastError,
+			"next_attempt_at": message.NextAttemptAt,
+			"dead_at":         message.DeadAt,
+		}).Error
+	}
+
+	r.delivered.Add(1)
+
+	return true, tx.Model(message).Update("delivered_at", time.Now()).Error
+}
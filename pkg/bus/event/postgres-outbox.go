@@ -0,0 +1,132 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"gorm.io/gorm"
+	"time"
+)
+
+type OutboxMessage struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement"`
+	EventName   string     `gorm:"type:varchar(255)"`
+	Payload     string     `gorm:"type:jsonb"`
+	OccurredAt  time.Time  `gorm:"autoCreateTime"`
+	DeliveredAt *time.Time `gorm:"index"`
+	Attempts    int        `gorm:"type:integer;default:0"`
+	LastError   string     `gorm:"type:text"`
+	// NextAttemptAt delays the retry of a message that failed, DeadAt parks a message that will not
+	// be relayed again until it is replayed.
+	NextAttemptAt *time.Time
+	DeadAt        *time.Time `gorm:"index"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "event_outbox"
+}
+
+// Fail records a failed relay of the message, scheduling its retry following policy or parking it as
+// dead once policy is exhausted or err is not retryable.
+func (m *OutboxMessage) Fail(err error, policy command.RetryPolicy, now time.Time) {
+	m.Attempts++
+	m.LastError = err.Error()
+
+	if m.Attempts >= policy.MaxAttempts || !policy.IsRetryable(err) {
+		m.NextAttemptAt = nil
+		m.DeadAt = &now
+		return
+	}
+
+	nextAttemptAt := now.Add(policy.Backoff(m.Attempts))
+	m.NextAttemptAt = &nextAttemptAt
+}
+
+// PostgresOutbox stores events in the same transaction as the aggregate that recorded them,
+// an OutboxRelay publishes them afterwards.
+type PostgresOutbox struct {
+	DB *gorm.DB
+}
+
+func NewPostgresOutbox(db *gorm.DB) (*PostgresOutbox, error) {
+	if err := db.AutoMigrate(&OutboxMessage{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresOutbox{DB: db}, nil
+}
+
+// Append writes the events using tx, they are only relayed if tx commits.
+func (o *PostgresOutbox) Append(tx *gorm.DB, events ...bus.Dto) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	messages := make([]*OutboxMessage, len(events))
+	for i, event := range events {
+		name := bus.NameOf(event)
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", name, err)
+		}
+
+		messages[i] = &OutboxMessage{EventName: name, Payload: string(payload)}
+	}
+
+	return tx.Create(messages).Error
+}
+
+// Replay marks the messages with an id within [fromId, toId], dead ones included, as pending so they
+// are relayed again.
+func (o *PostgresOutbox) Replay(ctx context.Context, fromId int64, toId int64) (int64, error) {
+	result := o.DB.WithContext(ctx).
+		Model(&OutboxMessage{}).
+		Where("id BETWEEN ? AND ?", fromId, toId).
+		Updates(map[string]interface{}{
+			"delivered_at":    nil,
+			"attempts":        0,
+			"last_error":      "",
+			"next_attempt_at": nil,
+			"dead_at":         nil,
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+type OutboxStats struct {
+	Pending int64 `json:"pending"`
+	// Dead counts the messages parked after exhausting their attempts, see PostgresOutbox.Replay.
+	Dead int64 `json:"dead"`
+	// Lag is the age of the oldest pending message.
+	Lag       time.Duration `json:"lag"`
+	Delivered uint64        `json:"delivered"`
+	Failed    uint64        `json:"failed"`
+}
+
+func (o *PostgresOutbox) pendingStats(ctx context.Context) (*OutboxStats, error) {
+	var stats struct {
+		Pending  int64
+		Dead     int64
+		OldestAt *time.Time
+	}
+
+	result := o.DB.WithContext(ctx).
+		Model(&OutboxMessage{}).
+		Select("COUNT(*) FILTER (WHERE dead_at IS NULL) AS pending, " +
+			"COUNT(*) FILTER (WHERE dead_at IS NOT NULL) AS dead, " +
+			"MIN(occurred_at) FILTER (WHERE dead_at IS NULL) AS oldest_at").
+		Where("delivered_at IS NULL").
+		Scan(&stats)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	outboxStats := &OutboxStats{Pending: stats.Pending, Dead: stats.Dead}
+	if stats.OldestAt != nil {
+		outboxStats.Lag = time.Since(*stats.OldestAt)
+	}
+
+	return outboxStats, nil
+}
@@ -0,0 +1,50 @@
+package event_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func outboxRetryPolicy() command.RetryPolicy {
+	return command.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute, Multiplier: 2}
+}
+
+func TestOutboxMessage_Fail_RetriesWithBackoffThenParksAsDead(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	message := &event.OutboxMessage{ID: 1, EventName: "user-created"}
+
+	message.Fail(errors.New("topic unavailable"), outboxRetryPolicy(), now)
+	require.NotNil(t, message.NextAttemptAt)
+	assert.Equal(t, now.Add(time.Second), *message.NextAttemptAt)
+	assert.Nil(t, message.DeadAt)
+
+	message.Fail(errors.New("topic unavailable"), outboxRetryPolicy(), now)
+	require.NotNil(t, message.NextAttemptAt)
+	assert.Equal(t, now.Add(2*time.Second), *message.NextAttemptAt)
+	assert.Nil(t, message.DeadAt)
+
+	message.Fail(errors.New("topic unavailable"), outboxRetryPolicy(), now)
+	assert.Equal(t, 3, message.Attempts)
+	assert.Equal(t, "topic unavailable", message.LastError)
+	assert.Nil(t, message.NextAttemptAt)
+	require.NotNil(t, message.DeadAt)
+	assert.Equal(t, now, *message.DeadAt)
+}
+
+func TestOutboxMessage_Fail_ParksUndecodableMessagesRightAway(t *testing.T) {
+	now := time.Now()
+	message := &event.OutboxMessage{ID: 1, EventName: "user-created", Payload: "{"}
+
+	message.Fail(bus.NewInvalidDto("dto payload can not be decoded"), outboxRetryPolicy(), now)
+
+	assert.Equal(t, 1, message.Attempts)
+	require.NotNil(t, message.DeadAt)
+	assert.Nil(t, message.NextAttemptAt)
+}
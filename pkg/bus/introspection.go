@@ -0,0 +1,65 @@
+package bus
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// HandlerInfo describes a message registered in a bus and how it has been handled so far.
+type HandlerInfo struct {
+	Name       string `json:"name"`
+	Handler    string `json:"handler"`
+	Dispatched uint64 `json:"dispatched"`
+	Failed     uint64 `json:"failed"`
+	Retried    uint64 `json:"retried"`
+	InFlight   int64  `json:"in_flight"`
+}
+
+// Counters tracks how the messages of a single name are handled.
+type Counters struct {
+	dispatched atomic.Uint64
+	failed     atomic.Uint64
+	retried    atomic.Uint64
+	inFlight   atomic.Int64
+}
+
+func (c *Counters) Dispatched() {
+	c.dispatched.Add(1)
+}
+
+func (c *Counters) Failed() {
+	c.failed.Add(1)
+}
+
+func (c *Counters) Retried() {
+	c.retried.Add(1)
+}
+
+// Started and Finished bracket work running in the background, e.g. async commands and their retries.
+func (c *Counters) Started() {
+	c.inFlight.Add(1)
+}
+
+func (c *Counters) Finished() {
+	c.inFlight.Add(-1)
+}
+
+func (c *Counters) Info(name string, handler interface{}) HandlerInfo {
+	return HandlerInfo{
+		Name:       name,
+		Handler:    HandlerType(handler),
+		Dispatched: c.dispatched.Load(),
+		Failed:     c.failed.Load(),
+		Retried:    c.retried.Load(),
+		InFlight:   c.inFlight.Load(),
+	}
+}
+
+// HandlerType returns the type of handler, looking through adapters exposing the handler they wrap.
+func HandlerType(handler interface{}) string {
+	if wrapper, ok := handler.(interface{ Unwrap() interface{} }); ok {
+		handler = wrapper.Unwrap()
+	}
+
+	return fmt.Sprintf("%T", handler)
+}
@@ -0,0 +1,23 @@
+package bus
+
+// MiddlewareOptOut is implemented by DTOs that must bypass some of the named
+// middlewares registered on the bus they are dispatched through.
+type MiddlewareOptOut interface {
+	SkipMiddlewares() []string
+}
+
+// SkipsMiddleware reports whether the dto opted out of the middleware registered under name.
+func SkipsMiddleware(dto Dto, name string) bool {
+	optOut, ok := dto.(MiddlewareOptOut)
+	if !ok {
+		return false
+	}
+
+	for _, skipped := range optOut.SkipMiddlewares() {
+		if skipped == name {
+			return true
+		}
+	}
+
+	return false
+}
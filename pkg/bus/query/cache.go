@@ -0,0 +1,192 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/cache"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"sync"
+	"time"
+)
+
+// CacheMiddlewareName is the name the QueryCache middleware is expected to be used with, queries
+// can opt out of it through bus.MiddlewareOptOut.
+const CacheMiddlewareName = "cache"
+
+// CacheKeyer lets a query choose its cache key, by default it is derived from its JSON encoding.
+type CacheKeyer interface {
+	CacheKey() string
+}
+
+type cachedQuery struct {
+	ttl    time.Duration
+	decode func(payload []byte) (interface{}, error)
+}
+
+// QueryCache caches the responses of the queries opted in through CacheQuery and evicts them
+// when the commands registered through EvictOn succeed.
+type QueryCache struct {
+	cache     cache.Cache
+	queries   map[string]cachedQuery
+	evictions map[string]func(command bus.Dto) []bus.Dto
+	lock      sync.RWMutex
+	l         shared_image_infrastructure.Logger
+}
+
+func NewQueryCache(c cache.Cache, l shared_image_infrastructure.Logger) *QueryCache {
+	return &QueryCache{
+		cache:     c,
+		queries:   make(map[string]cachedQuery),
+		evictions: make(map[string]func(command bus.Dto) []bus.Dto),
+		l:         l,
+	}
+}
+
+// CacheQuery caches the R responses of the queries of type Q for ttl.
+func CacheQuery[Q bus.Dto, R any](qc *QueryCache, ttl time.Duration) {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+
+	qc.queries[bus.NameOf(bus.New[Q]())] = cachedQuery{
+		ttl: ttl,
+		decode: func(payload []byte) (interface{}, error) {
+			var response R
+			err := json.Unmarshal(payload, &response)
+			return response, err
+		},
+	}
+}
+
+// EvictOn evicts the cached responses of the queries returned by queries once a command of type C
+// has been handled successfully.
+func EvictOn[C bus.Dto](qc *QueryCache, queries func(command C) []bus.Dto) {
+	qc.lock.Lock()
+	defer qc.lock.Unlock()
+
+	qc.evictions[bus.NameOf(bus.New[C]())] = func(command bus.Dto) []bus.Dto {
+		c, ok := command.(C)
+		if !ok {
+			return nil
+		}
+		return queries(c)
+	}
+}
+
+// Middleware serves the cached queries from the cache, storing the response of the handler on a miss.
+// Cache failures are logged and the query is handled as if it was not cached.
+func (qc *QueryCache) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, query bus.Dto) (interface{}, error) {
+			qc.lock.RLock()
+			cached, ok := qc.queries[bus.NameOf(query)]
+			qc.lock.RUnlock()
+			if !ok {
+				return next(ctx, query)
+			}
+
+			key, err := CacheKey(query)
+			if err != nil {
+				return next(ctx, query)
+			}
+
+			if response, hit := qc.get(ctx, key, cached); hit {
+				return response, nil
+			}
+
+			response, err := next(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			qc.set(ctx, key, response, cached.ttl)
+
+			return response, nil
+		}
+	}
+}
+
+// InvalidationMiddleware evicts the queries registered through EvictOn for the handled command.
+func (qc *QueryCache) InvalidationMiddleware() command.Middleware {
+	return func(next command.HandlerFunc) command.HandlerFunc {
+		return func(ctx context.Context, c bus.Dto) error {
+			if err := next(ctx, c); err != nil {
+				return err
+			}
+
+			qc.lock.RLock()
+			queries, ok := qc.evictions[bus.NameOf(c)]
+			qc.lock.RUnlock()
+			if !ok {
+				return nil
+			}
+
+			if err := qc.Evict(ctx, queries(c)...); err != nil {
+				qc.l.Error(ctx, "error evicting cached queries", map[string]interface{}{"error": err.Error(), "command": bus.NameOf(c)})
+			}
+
+			return nil
+		}
+	}
+}
+
+// Evict removes the cached responses of queries.
+func (qc *QueryCache) Evict(ctx context.Context, queries ...bus.Dto) error {
+	keys := make([]string, 0, len(queries))
+	for _, query := range queries {
+		key, err := CacheKey(query)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	return qc.cache.Delete(ctx, keys...)
+}
+
+// CacheKey returns the key the response of query is cached under: its name followed by its
+// CacheKey, or by a hash of its JSON encoding.
+func CacheKey(query bus.Dto) (string, error) {
+	if keyer, ok := query.(CacheKeyer); ok {
+		return bus.NameOf(query) + ":" + keyer.CacheKey(), nil
+	}
+
+	payload, err := json.Marshal(query)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(payload)
+
+	return bus.NameOf(query) + ":" + hex.EncodeToString(hash[:]), nil
+}
+
+func (qc *QueryCache) get(ctx context.Context, key string, cached cachedQuery) (interface{}, bool) {
+	payload, ok, err := qc.cache.Get(ctx, key)
+	if err != nil {
+		qc.l.Warn(ctx, "error reading cached query", map[string]interface{}{"error": err.Error(), "key": key})
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	response, err := cached.decode(payload)
+	if err != nil {
+		qc.l.Warn(ctx, "error decoding cached query", map[string]interface{}{"error": err.Error(), "key": key})
+		return nil, false
+	}
+
+	return response, true
+}
+
+func (qc *QueryCache) set(ctx context.Context, key string, response interface{}, ttl time.Duration) {
+	payload, err := json.Marshal(response)
+	if err == nil {
+		err = qc.cache.Set(ctx, key, payload, ttl)
+	}
+	if err != nil {
+		qc.l.Warn(ctx, "error caching query", map[string]interface{}{"error": err.Error(), "key": key})
+	}
+}
@@ -0,0 +1,92 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/query"
+	"github.com/mik3lon/starter-template/pkg/cache"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type findNameQuery struct {
+	Key string
+}
+
+type nameResponse struct {
+	Name string
+}
+
+type countingNameHandler struct {
+	calls int
+}
+
+func (h *countingNameHandler) Handle(ctx context.Context, q *findNameQuery) (*nameResponse, error) {
+	h.calls++
+	return &nameResponse{Name: q.Key}, nil
+}
+
+type renameCommand struct {
+	Key string
+}
+
+type renameHandler struct{}
+
+func (h renameHandler) Handle(ctx context.Context, c *renameCommand) error {
+	return nil
+}
+
+func newCachedBuses(t *testing.T) (*query.QueryBus, *command.CommandBus, *countingNameHandler) {
+	l := shared_image_infrastructure.NewZerologAdapter()
+	qc := query.NewQueryCache(cache.NewInMemoryLRUCache(10), l)
+	query.CacheQuery[*findNameQuery, *nameResponse](qc, time.Minute)
+	query.EvictOn(qc, func(c *renameCommand) []bus.Dto {
+		return []bus.Dto{&findNameQuery{Key: c.Key}}
+	})
+
+	qb := query.InitQueryBus(l)
+	qb.Use(query.CacheMiddlewareName, qc.Middleware())
+	handler := &countingNameHandler{}
+	require.NoError(t, query.Register[*findNameQuery, *nameResponse](qb, handler))
+
+	cb := command.InitCommandBus(l)
+	cb.Use(query.CacheMiddlewareName, qc.InvalidationMiddleware())
+	require.NoError(t, command.Register[*renameCommand](cb, renameHandler{}))
+
+	return qb, cb, handler
+}
+
+func TestQueryCache_ServesCachedResponses(t *testing.T) {
+	qb, _, handler := newCachedBuses(t)
+	ctx := context.Background()
+
+	first, err := query.Ask[*findNameQuery, *nameResponse](ctx, qb, &findNameQuery{Key: "john"})
+	require.NoError(t, err)
+	second, err := query.Ask[*findNameQuery, *nameResponse](ctx, qb, &findNameQuery{Key: "john"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, handler.calls)
+	assert.Equal(t, first, second)
+}
+
+func TestQueryCache_EvictsOnCommand(t *testing.T) {
+	qb, cb, handler := newCachedBuses(t)
+	ctx := context.Background()
+
+	_, err := query.Ask[*findNameQuery, *nameResponse](ctx, qb, &findNameQuery{Key: "john"})
+	require.NoError(t, err)
+	_, err = query.Ask[*findNameQuery, *nameResponse](ctx, qb, &findNameQuery{Key: "jane"})
+	require.NoError(t, err)
+	require.NoError(t, cb.Dispatch(ctx, &renameCommand{Key: "john"}))
+	_, err = query.Ask[*findNameQuery, *nameResponse](ctx, qb, &findNameQuery{Key: "john"})
+	require.NoError(t, err)
+	_, err = query.Ask[*findNameQuery, *nameResponse](ctx, qb, &findNameQuery{Key: "jane"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, handler.calls)
+}
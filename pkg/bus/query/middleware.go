@@ -0,0 +1,61 @@
+package query
+
+import (
+	"context"
+	"github.com/mik3lon/starter-template/pkg/bus"
+)
+
+// HandlerFunc is the signature every middleware wraps, ending in the QueryHandler itself.
+type HandlerFunc func(ctx context.Context, query bus.Dto) (interface{}, error)
+
+// Middleware decorates the next HandlerFunc in the chain.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+type namedMiddleware struct {
+	name       string
+	middleware Middleware
+}
+
+// Use appends a middleware to the bus. Middlewares run in registration order,
+// the first one registered being the outermost. Queries can opt out of it by
+// returning its name from bus.MiddlewareOptOut.
+func (bus *QueryBus) Use(name string, middleware Middleware) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.middlewares = append(bus.middlewares, namedMiddleware{name: name, middleware: middleware})
+}
+
+func (bus *QueryBus) chain(handler QueryHandler, query bus.Dto) HandlerFunc {
+	bus.lock.Lock()
+	middlewares := make([]namedMiddleware, len(bus.middlewares))
+	copy(middlewares, bus.middlewares)
+	bus.lock.Unlock()
+
+	var next HandlerFunc = handler.Handle
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if skipsMiddleware(query, middlewares[i].name) {
+			continue
+		}
+		next = middlewares[i].middleware(next)
+	}
+
+	return next
+}
+
+func skipsMiddleware(query bus.Dto, name string) bool {
+	return bus.SkipsMiddleware(query, name)
+}
+
+// ValidationMiddleware rejects queries failing bus.Validate before they reach their handler.
+func ValidationMiddleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, query bus.Dto) (interface{}, error) {
+			if err := bus.Validate(query); err != nil {
+				return nil, err
+			}
+
+			return next(ctx, query)
+		}
+	}
+}
@@ -2,9 +2,13 @@ package query
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/mik3lon/starter-template/pkg/bus"
 	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"sort"
 	"sync"
+	"time"
 )
 
 type Bus interface {
@@ -13,14 +17,22 @@ type Bus interface {
 }
 
 type QueryBus struct {
-	handlers map[string]QueryHandler
-	lock     sync.Mutex
-	logger   shared_image_infrastructure.Logger
+	handlers    map[string]QueryHandler
+	registry    *bus.Registry
+	lock        sync.Mutex
+	logger      shared_image_infrastructure.Logger
+	middlewares []namedMiddleware
+	timeouts    map[string]time.Duration
+	timeout     time.Duration
+	counters    map[string]*bus.Counters
 }
 
 func InitQueryBus(l shared_image_infrastructure.Logger) *QueryBus {
 	return &QueryBus{
 		handlers: make(map[string]QueryHandler, 0),
+		registry: bus.NewRegistry(),
+		timeouts: make(map[string]time.Duration, 0),
+		counters: make(map[string]*bus.Counters, 0),
 		lock:     sync.Mutex{},
 		logger:   l,
 	}
@@ -48,27 +60,71 @@ func (i QueryNotRegistered) Error() string {
 	return i.message
 }
 
-func NewQueryNotRegistered(message string, queryName string) QueryAlreadyRegistered {
-	return QueryAlreadyRegistered{message: message, queryName: queryName}
+func NewQueryNotRegistered(message string, queryName string) QueryNotRegistered {
+	return QueryNotRegistered{message: message, queryName: queryName}
 }
 
 func (bus *QueryBus) RegisterQuery(query bus.Dto, handler QueryHandler) error {
 	bus.lock.Lock()
 	defer bus.lock.Unlock()
 
-	queryName := query.Id()
+	queryName := queryName(query)
+
+	// The registry tells which types collide on the name, the handlers only catch the same type twice.
+	if err := bus.registry.Register(query); err != nil {
+		return err
+	}
 
 	if _, ok := bus.handlers[queryName]; ok {
 		return NewQueryAlreadyRegistered("Query already registered", queryName)
 	}
 
 	bus.handlers[queryName] = handler
+	bus.counters[queryName] = newCounters()
 
 	return nil
 }
 
+// Registry exposes the queries known by the bus.
+func (bus *QueryBus) Registry() *bus.Registry {
+	return bus.registry
+}
+
+// Handlers lists the registered queries, sorted by name, with their handler and counters.
+func (bus *QueryBus) Handlers() []bus.HandlerInfo {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	return handlerInfos(bus.handlers, bus.counters)
+}
+
+func handlerInfos(handlers map[string]QueryHandler, counters map[string]*bus.Counters) []bus.HandlerInfo {
+	infos := make([]bus.HandlerInfo, 0, len(handlers))
+	for name, handler := range handlers {
+		infos = append(infos, counters[name].Info(name, handler))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
+
+func newCounters() *bus.Counters {
+	return &bus.Counters{}
+}
+
+func (bus *QueryBus) countersFor(queryName string) *bus.Counters {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	if counters, ok := bus.counters[queryName]; ok {
+		return counters
+	}
+
+	return newCounters()
+}
+
 func (bus *QueryBus) Ask(ctx context.Context, query bus.Dto) (interface{}, error) {
-	queryName := query.Id()
+	queryName := queryName(query)
 
 	if handler, ok := bus.handlers[queryName]; ok {
 		response, err := bus.doAsk(ctx, handler, query)
@@ -82,8 +138,65 @@ func (bus *QueryBus) Ask(ctx context.Context, query bus.Dto) (interface{}, error
 	return nil, NewQueryNotRegistered("Query not registered", queryName)
 }
 
+// SetTimeout bounds the execution of the handler of the given query, once expired the bus
+// returns a bus.HandlerTimeout.
+func (bus *QueryBus) SetTimeout(query bus.Dto, timeout time.Duration) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.timeouts[queryName(query)] = timeout
+}
+
+// SetDefaultTimeout bounds the execution of the queries without a timeout of their own, zero means no bound.
+func (bus *QueryBus) SetDefaultTimeout(timeout time.Duration) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.timeout = timeout
+}
+
 func (bus *QueryBus) doAsk(ctx context.Context, handler QueryHandler, query bus.Dto) (interface{}, error) {
-	return handler.Handle(ctx, query)
+	name := queryName(query)
+	counters := bus.countersFor(name)
+	counters.Dispatched()
+
+	response, err := askWithTimeout(ctx, name, bus.timeoutFor(name), bus.chain(handler, query), query)
+	if err != nil {
+		counters.Failed()
+		logPanic(ctx, bus.logger, name, err)
+	}
+
+	return response, err
+}
+
+func (bus *QueryBus) timeoutFor(queryName string) time.Duration {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	if timeout, ok := bus.timeouts[queryName]; ok {
+		return timeout
+	}
+
+	return bus.timeout
+}
+
+func askWithTimeout(ctx context.Context, name string, timeout time.Duration, next HandlerFunc, query bus.Dto) (interface{}, error) {
+	return bus.WithTimeout(ctx, name, timeout, func(ctx context.Context) (interface{}, error) {
+		return bus.Recover(name, func() (interface{}, error) {
+			return next(ctx, query)
+		})
+	})
+}
+
+func logPanic(ctx context.Context, l shared_image_infrastructure.Logger, name string, err error) {
+	var handlerPanic bus.HandlerPanic
+	if errors.As(err, &handlerPanic) {
+		l.Error(ctx, "query handler panicked", map[string]interface{}{
+			"query": name,
+			"panic": fmt.Sprint(handlerPanic.Value),
+			"stack": handlerPanic.Stack,
+		})
+	}
 }
 
 type QueryNotValid struct {
@@ -93,3 +206,7 @@ type QueryNotValid struct {
 func (i QueryNotValid) Error() string {
 	return i.message
 }
+
+func queryName(query bus.Dto) string {
+	return bus.NameOf(query)
+}
@@ -0,0 +1,144 @@
+package query_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/query"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testQuery struct {
+	skipped []string
+}
+
+func (q testQuery) Id() string {
+	return "test-query"
+}
+
+func (q testQuery) SkipMiddlewares() []string {
+	return q.skipped
+}
+
+type testQueryHandler struct{}
+
+func (h testQueryHandler) Handle(ctx context.Context, q bus.Dto) (interface{}, error) {
+	return "handler", nil
+}
+
+func suffixMiddleware(suffix string) query.Middleware {
+	return func(next query.HandlerFunc) query.HandlerFunc {
+		return func(ctx context.Context, q bus.Dto) (interface{}, error) {
+			response, err := next(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+			return response.(string) + "<" + suffix, nil
+		}
+	}
+}
+
+func TestQueryBus_Ask_RunsMiddlewaresInRegistrationOrder(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	qb.Use("first", suffixMiddleware("first"))
+	qb.Use("second", suffixMiddleware("second"))
+	require.NoError(t, qb.RegisterQuery(&testQuery{}, testQueryHandler{}))
+
+	response, err := qb.Ask(context.Background(), &testQuery{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "handler<second<first", response)
+}
+
+func TestQueryBus_Ask_SkipsOptedOutMiddlewares(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	qb.Use("first", suffixMiddleware("first"))
+	qb.Use("second", suffixMiddleware("second"))
+	require.NoError(t, qb.RegisterQuery(&testQuery{}, testQueryHandler{}))
+
+	response, err := qb.Ask(context.Background(), &testQuery{skipped: []string{"second"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "handler<first", response)
+}
+
+type typedTestQueryHandler struct{}
+
+func (h typedTestQueryHandler) Handle(ctx context.Context, q *testQuery) (string, error) {
+	return "typed", nil
+}
+
+func TestAsk_ReturnsTypedResponse(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, query.Register[*testQuery, string](qb, typedTestQueryHandler{}))
+
+	response, err := query.Ask[*testQuery, string](context.Background(), qb, &testQuery{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "typed", response)
+}
+
+func TestAsk_FailsOnUnexpectedResponse(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, qb.RegisterQuery(&testQuery{}, testQueryHandler{}))
+
+	_, err := query.Ask[*testQuery, int](context.Background(), qb, &testQuery{})
+
+	assert.ErrorAs(t, err, new(query.UnexpectedResponse))
+}
+
+type slowQueryHandler struct{}
+
+func (h slowQueryHandler) Handle(ctx context.Context, q bus.Dto) (interface{}, error) {
+	time.Sleep(time.Second)
+	return "late", nil
+}
+
+func TestQueryBus_Ask_ReturnsHandlerTimeout(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	qb.SetDefaultTimeout(10 * time.Millisecond)
+	require.NoError(t, qb.RegisterQuery(&testQuery{}, slowQueryHandler{}))
+
+	response, err := qb.Ask(context.Background(), &testQuery{})
+
+	assert.Nil(t, response)
+	assert.ErrorAs(t, err, new(bus.HandlerTimeout))
+}
+
+type collidingQuery struct{}
+
+func (q collidingQuery) Id() string {
+	return "test-query"
+}
+
+func TestQueryBus_RegisterQuery_NamesCollidingTypes(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, qb.RegisterQuery(&testQuery{}, testQueryHandler{}))
+
+	err := qb.RegisterQuery(&collidingQuery{}, testQueryHandler{})
+
+	assert.IsType(t, bus.DtoAlreadyRegistered{}, err)
+	assert.ErrorContains(t, err, "*query_test.testQuery")
+	assert.ErrorContains(t, err, "*query_test.collidingQuery")
+}
+
+func TestQueryBus_Ask_FailsOnUnregisteredQueries(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+
+	_, err := qb.Ask(context.Background(), &testQuery{})
+
+	assert.IsType(t, query.QueryNotRegistered{}, err)
+}
+
+func TestQueryBus_RegisterQuery_FailsOnDuplicatedQueries(t *testing.T) {
+	qb := query.InitQueryBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, qb.RegisterQuery(&testQuery{}, testQueryHandler{}))
+
+	err := qb.RegisterQuery(&testQuery{}, testQueryHandler{})
+
+	assert.IsType(t, query.QueryAlreadyRegistered{}, err)
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus"
+)
+
+// TypedQueryHandler handles queries of type Q answering with R, the bus does the type assertions for it.
+type TypedQueryHandler[Q bus.Dto, R any] interface {
+	Handle(ctx context.Context, query Q) (R, error)
+}
+
+type typedQueryHandler[Q bus.Dto, R any] struct {
+	handler TypedQueryHandler[Q, R]
+}
+
+func (t typedQueryHandler[Q, R]) Handle(ctx context.Context, query bus.Dto) (interface{}, error) {
+	q, ok := query.(Q)
+	if !ok {
+		return nil, bus.NewInvalidDto(fmt.Sprintf("query %s is %T, expected %T", bus.NameOf(query), query, q))
+	}
+
+	response, err := t.handler.Handle(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Unwrap returns the adapted handler, so introspection reports its type.
+func (t typedQueryHandler[Q, R]) Unwrap() interface{} {
+	return t.handler
+}
+
+// Handler adapts a TypedQueryHandler to a QueryHandler.
+func Handler[Q bus.Dto, R any](handler TypedQueryHandler[Q, R]) QueryHandler {
+	return typedQueryHandler[Q, R]{handler: handler}
+}
+
+// Register registers handler for the queries of type Q.
+func Register[Q bus.Dto, R any](b Bus, handler TypedQueryHandler[Q, R]) error {
+	return b.RegisterQuery(bus.New[Q](), Handler(handler))
+}
+
+// Ask asks q to b and returns its response as R.
+func Ask[Q bus.Dto, R any](ctx context.Context, b Bus, q Q) (R, error) {
+	var zero R
+
+	response, err := b.Ask(ctx, q)
+	if err != nil {
+		return zero, err
+	}
+
+	r, ok := response.(R)
+	if !ok {
+		return zero, NewUnexpectedResponse(bus.NameOf(q), response, zero)
+	}
+
+	return r, nil
+}
+
+type UnexpectedResponse struct {
+	message   string
+	queryName string
+}
+
+func (u UnexpectedResponse) Error() string {
+	return u.message
+}
+
+func NewUnexpectedResponse(queryName string, response interface{}, expected interface{}) UnexpectedResponse {
+	return UnexpectedResponse{
+		message:   fmt.Sprintf("query %s answered %T, expected %T", queryName, response, expected),
+		queryName: queryName,
+	}
+}
@@ -0,0 +1,132 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Registry is the identity of the messages known by a bus: it keeps the Go type behind every Dto
+// name, so they can be serialized and rebuilt by transports that do not keep them in memory, and
+// makes sure two types never share a name.
+type Registry struct {
+	types map[string]reflect.Type
+	lock  sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]reflect.Type)}
+}
+
+type DtoAlreadyRegistered struct {
+	message string
+	id      string
+}
+
+func (i DtoAlreadyRegistered) Error() string {
+	return i.message
+}
+
+func NewDtoAlreadyRegistered(id string, registered reflect.Type, dtoType reflect.Type) DtoAlreadyRegistered {
+	return DtoAlreadyRegistered{
+		message: fmt.Sprintf("Dto already registered: %s is used by %s and %s", id, registered, dtoType),
+		id:      id,
+	}
+}
+
+type DtoNotRegistered struct {
+	message string
+	id      string
+}
+
+func (i DtoNotRegistered) Error() string {
+	return i.message
+}
+
+func NewDtoNotRegistered(id string) DtoNotRegistered {
+	return DtoNotRegistered{message: "Dto not registered: " + id, id: id}
+}
+
+// Register adds a pointer to struct Dto, failing if another type already uses its name.
+func (r *Registry) Register(dto Dto) error {
+	dtoType := reflect.TypeOf(dto)
+	if dtoType == nil || dtoType.Kind() != reflect.Ptr || dtoType.Elem().Kind() != reflect.Struct {
+		return NewInvalidDto("only pointer to dtos can be registered")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	id := NameOf(dto)
+	if registered, ok := r.types[id]; ok && registered != dtoType {
+		return NewDtoAlreadyRegistered(id, registered, dtoType)
+	}
+
+	r.types[id] = dtoType
+
+	return nil
+}
+
+// Encode returns the name and JSON payload of a registered Dto.
+func (r *Registry) Encode(dto Dto) (string, []byte, error) {
+	id := NameOf(dto)
+
+	r.lock.RLock()
+	_, ok := r.types[id]
+	r.lock.RUnlock()
+
+	if !ok {
+		return "", nil, NewDtoNotRegistered(id)
+	}
+
+	payload, err := json.Marshal(dto)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, payload, nil
+}
+
+// Decode rebuilds the Dto registered under the name id from its JSON payload.
+func (r *Registry) Decode(id string, payload []byte) (Dto, error) {
+	r.lock.RLock()
+	dtoType, ok := r.types[id]
+	r.lock.RUnlock()
+
+	if !ok {
+		return nil, NewDtoNotRegistered(id)
+	}
+
+	dto := reflect.New(dtoType.Elem()).Interface()
+	if err := json.Unmarshal(payload, dto); err != nil {
+		return nil, NewInvalidDto("dto payload can not be decoded: " + err.Error())
+	}
+
+	return dto, nil
+}
+
+// Names returns the sorted names of the registered Dtos.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// TypeOf returns the type registered under the name id.
+func (r *Registry) TypeOf(id string) (reflect.Type, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	dtoType, ok := r.types[id]
+
+	return dtoType, ok
+}
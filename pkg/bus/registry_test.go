@@ -0,0 +1,72 @@
+package bus_test
+
+import (
+	"testing"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type registeredDto struct {
+	Name string
+}
+
+func (d registeredDto) Id() string {
+	return "registered-dto"
+}
+
+type collidingDto struct{}
+
+func (d collidingDto) Id() string {
+	return "registered-dto"
+}
+
+func TestRegistry_EncodeAndDecode(t *testing.T) {
+	r := bus.NewRegistry()
+	require.NoError(t, r.Register(&registeredDto{}))
+
+	id, payload, err := r.Encode(&registeredDto{Name: "john"})
+	require.NoError(t, err)
+	dto, err := r.Decode(id, payload)
+
+	require.NoError(t, err)
+	assert.Equal(t, &registeredDto{Name: "john"}, dto)
+}
+
+func TestRegistry_Register_FailsOnDuplicatedId(t *testing.T) {
+	r := bus.NewRegistry()
+	require.NoError(t, r.Register(&registeredDto{}))
+
+	err := r.Register(&collidingDto{})
+
+	assert.ErrorAs(t, err, new(bus.DtoAlreadyRegistered))
+}
+
+func TestRegistry_Decode_FailsOnUnknownId(t *testing.T) {
+	r := bus.NewRegistry()
+
+	_, err := r.Decode("unknown", []byte("{}"))
+
+	assert.ErrorAs(t, err, new(bus.DtoNotRegistered))
+}
+
+type unnamedDto struct{}
+
+func TestNameOf_DerivesNameFromType(t *testing.T) {
+	assert.Equal(t, "github.com/mik3lon/starter-template/pkg/bus_test.unnamedDto", bus.NameOf(&unnamedDto{}))
+	assert.Equal(t, bus.NameOf(&unnamedDto{}), bus.NameOf(unnamedDto{}))
+}
+
+func TestNameOf_UsesIdOverride(t *testing.T) {
+	assert.Equal(t, "registered-dto", bus.NameOf(&registeredDto{}))
+	assert.Equal(t, "registered-dto", bus.NameOf((*registeredDto)(nil)))
+}
+
+func TestRegistry_Names(t *testing.T) {
+	r := bus.NewRegistry()
+	require.NoError(t, r.Register(&unnamedDto{}))
+	require.NoError(t, r.Register(&registeredDto{}))
+
+	assert.Equal(t, []string{"github.com/mik3lon/starter-template/pkg/bus_test.unnamedDto", "registered-dto"}, r.Names())
+}
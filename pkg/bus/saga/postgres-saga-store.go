@@ -0,0 +1,148 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type sagaRecord struct {
+	Name          string    `gorm:"type:varchar(255);primaryKey"`
+	CorrelationId string    `gorm:"type:varchar(255);primaryKey"`
+	Status        string    `gorm:"type:varchar(32)"`
+	Step          int       `gorm:"type:integer"`
+	Data          string    `gorm:"type:jsonb"`
+	Error         string    `gorm:"type:text"`
+	Deadline      time.Time `gorm:"index"`
+	StartedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (sagaRecord) TableName() string {
+	return "sagas"
+}
+
+// PostgresStore is a Store using Gorm, shared by every instance using the database.
+type PostgresStore struct {
+	DB *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) (*PostgresStore, error) {
+	if err := db.AutoMigrate(&sagaRecord{}); err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{DB: db}, nil
+}
+
+func (s *PostgresStore) Create(ctx context.Context, saga *Saga) error {
+	record, err := newSagaRecord(saga)
+	if err != nil {
+		return err
+	}
+
+	result := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create saga: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return NewSagaAlreadyStarted(saga.Name, saga.CorrelationId)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Save(ctx context.Context, saga *Saga) error {
+	record, err := newSagaRecord(saga)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DB.WithContext(ctx).Save(record).Error; err != nil {
+		return fmt.Errorf("failed to save saga: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Find(ctx context.Context, name string, correlationId string) (*Saga, error) {
+	var record sagaRecord
+	result := s.DB.WithContext(ctx).First(&record, "name = ? AND correlation_id = ?", name, correlationId)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, NewSagaNotFound(name, correlationId)
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return record.toSaga()
+}
+
+func (s *PostgresStore) ClaimStale(ctx context.Context, now time.Time, lease time.Duration) ([]*Saga, error) {
+	var records []sagaRecord
+	result := s.DB.WithContext(ctx).Raw(
+		`UPDATE sagas SET deadline = ? WHERE (name, correlation_id) IN (
+			SELECT name, correlation_id FROM sagas
+			WHERE status IN (?, ?) AND deadline < ?
+			ORDER BY started_at
+			FOR UPDATE SKIP LOCKED
+		) RETURNING *`,
+		now.Add(lease), string(StatusRunning), string(StatusCompensating), now,
+	).Scan(&records)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	sagas := make([]*Saga, len(records))
+	for i, record := range records {
+		saga, err := record.toSaga()
+		if err != nil {
+			return nil, err
+		}
+		sagas[i] = saga
+	}
+
+	return sagas, nil
+}
+
+func newSagaRecord(saga *Saga) (*sagaRecord, error) {
+	data, err := json.Marshal(saga.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode saga data: %w", err)
+	}
+
+	return &sagaRecord{
+		Name:          saga.Name,
+		CorrelationId: saga.CorrelationId,
+		Status:        string(saga.Status),
+		Step:          saga.Step,
+		Data:          string(data),
+		Error:         saga.Error,
+		Deadline:      saga.Deadline,
+		StartedAt:     saga.StartedAt,
+		UpdatedAt:     saga.UpdatedAt,
+	}, nil
+}
+
+func (r sagaRecord) toSaga() (*Saga, error) {
+	var data map[string]string
+	if err := json.Unmarshal([]byte(r.Data), &data); err != nil {
+		return nil, fmt.Errorf("failed to decode saga data: %w", err)
+	}
+
+	return &Saga{
+		Name:          r.Name,
+		CorrelationId: r.CorrelationId,
+		Status:        Status(r.Status),
+		Step:          r.Step,
+		Data:          data,
+		Error:         r.Error,
+		Deadline:      r.Deadline,
+		StartedAt:     r.StartedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,242 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/event"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"sync"
+	"time"
+)
+
+// Manager runs sagas dispatching the commands of their steps through the command bus, keeping
+// their progress in a Store so sagas abandoned by a stopped instance are compensated by Run.
+type Manager struct {
+	store          Store
+	cb             command.Bus
+	l              shared_image_infrastructure.Logger
+	definitions    map[string]Definition
+	lock           sync.Mutex
+	defaultTimeout time.Duration
+	pollInterval   time.Duration
+}
+
+func NewManager(
+	store Store,
+	cb command.Bus,
+	l shared_image_infrastructure.Logger,
+	defaultTimeout time.Duration,
+	pollInterval time.Duration,
+) *Manager {
+	return &Manager{
+		store:          store,
+		cb:             cb,
+		l:              l,
+		definitions:    make(map[string]Definition),
+		defaultTimeout: defaultTimeout,
+		pollInterval:   pollInterval,
+	}
+}
+
+func (m *Manager) Register(definition Definition) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.definitions[definition.Name]; ok {
+		return fmt.Errorf("saga %s already registered", definition.Name)
+	}
+	m.definitions[definition.Name] = definition
+
+	return nil
+}
+
+// Start runs the saga name for correlationId until it completes or is compensated. It only fails
+// when the saga could not reach either, a compensated saga is an outcome reported by its Status.
+func (m *Manager) Start(ctx context.Context, name string, correlationId string, data map[string]string) (*Saga, error) {
+	definition, err := m.definition(name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s := &Saga{
+		Name:          name,
+		CorrelationId: correlationId,
+		Status:        StatusRunning,
+		Data:          data,
+		StartedAt:     now,
+		UpdatedAt:     now,
+	}
+	if len(definition.Steps) > 0 {
+		s.Deadline = m.deadline(definition.Steps[0])
+	}
+	if err := m.store.Create(ctx, s); err != nil {
+		return nil, err
+	}
+
+	return s, m.run(ctx, definition, s)
+}
+
+func (m *Manager) Find(ctx context.Context, name string, correlationId string) (*Saga, error) {
+	return m.store.Find(ctx, name, correlationId)
+}
+
+// StartOn returns an event handler starting the saga name for every E received, duplicated
+// deliveries of an event are ignored once its saga started.
+func StartOn[E bus.Dto](m *Manager, name string, start func(event E) (correlationId string, data map[string]string)) event.EventHandler {
+	return startOnHandler[E]{m: m, name: name, start: start}
+}
+
+type startOnHandler[E bus.Dto] struct {
+	m     *Manager
+	name  string
+	start func(event E) (string, map[string]string)
+}
+
+func (h startOnHandler[E]) Handle(ctx context.Context, e bus.Dto) error {
+	typed, ok := e.(E)
+	if !ok {
+		return bus.NewInvalidDto("Invalid event")
+	}
+
+	correlationId, data := h.start(typed)
+	_, err := h.m.Start(ctx, h.name, correlationId, data)
+	if errors.As(err, new(SagaAlreadyStarted)) {
+		return nil
+	}
+
+	return err
+}
+
+// Run compensates the sagas abandoned past their deadline until ctx is done. As the outcome of
+// the step they were running is unknown, that step is compensated too.
+func (m *Manager) Run(ctx context.Context) {
+	for {
+		stale, err := m.store.ClaimStale(ctx, time.Now(), m.defaultTimeout)
+		if err != nil {
+			m.l.Error(ctx, "error claiming stale sagas", map[string]interface{}{"error": err.Error()})
+		}
+
+		for _, s := range stale {
+			m.recover(ctx, s)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.pollInterval):
+		}
+	}
+}
+
+func (m *Manager) recover(ctx context.Context, s *Saga) {
+	definition, err := m.definition(s.Name)
+	if err != nil {
+		m.l.Error(ctx, "error recovering saga", map[string]interface{}{"saga": s.Name, "correlation_id": s.CorrelationId, "error": err.Error()})
+		return
+	}
+
+	if s.Status == StatusRunning {
+		s.Error = fmt.Sprintf("step %s abandoned past its deadline", definition.Steps[s.Step].Name)
+	}
+	if err := m.compensate(ctx, definition, s); err != nil {
+		m.l.Error(ctx, "error compensating saga", map[string]interface{}{"saga": s.Name, "correlation_id": s.CorrelationId, "error": err.Error()})
+	}
+}
+
+func (m *Manager) run(ctx context.Context, definition Definition, s *Saga) error {
+	for s.Step < len(definition.Steps) {
+		step := definition.Steps[s.Step]
+
+		err := m.dispatch(ctx, definition, step, step.Action(s))
+		if err != nil {
+			s.Error = err.Error()
+			// A timed out step may still apply once it returns, so it is compensated too.
+			if !errors.As(err, new(bus.HandlerTimeout)) {
+				s.Step--
+			}
+			return m.compensate(ctx, definition, s)
+		}
+
+		s.Step++
+		if s.Step < len(definition.Steps) {
+			if err := m.save(ctx, s, m.deadline(definition.Steps[s.Step])); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.Status = StatusCompleted
+	return m.save(ctx, s, time.Time{})
+}
+
+func (m *Manager) compensate(ctx context.Context, definition Definition, s *Saga) error {
+	s.Status = StatusCompensating
+
+	for ; s.Step >= 0; s.Step-- {
+		step := definition.Steps[s.Step]
+		if step.Compensation == nil {
+			continue
+		}
+
+		if err := m.save(ctx, s, m.deadline(step)); err != nil {
+			return err
+		}
+		if err := m.dispatch(ctx, definition, step, step.Compensation(s)); err != nil {
+			s.Status = StatusFailed
+			s.Error = fmt.Sprintf("%s, compensating step %s failed: %s", s.Error, step.Name, err.Error())
+			if saveErr := m.save(ctx, s, time.Time{}); saveErr != nil {
+				return errors.Join(err, saveErr)
+			}
+			return err
+		}
+	}
+
+	s.Status = StatusCompensated
+	return m.save(ctx, s, time.Time{})
+}
+
+func (m *Manager) dispatch(ctx context.Context, definition Definition, step Step, cmd bus.Dto) error {
+	_, err := bus.WithTimeout(ctx, definition.Name+"."+step.Name, m.timeout(step), func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, m.cb.Dispatch(ctx, cmd)
+	})
+
+	return err
+}
+
+func (m *Manager) save(ctx context.Context, s *Saga, deadline time.Time) error {
+	s.Deadline = deadline
+	s.UpdatedAt = time.Now()
+
+	// The progress must be kept even if the caller gave up on the saga meanwhile.
+	return m.store.Save(context.WithoutCancel(ctx), s)
+}
+
+// deadline leaves the step twice its timeout before considering the saga abandoned, so a saga
+// still saving its progress is not claimed by another instance.
+func (m *Manager) deadline(step Step) time.Time {
+	return time.Now().Add(2 * m.timeout(step))
+}
+
+func (m *Manager) timeout(step Step) time.Duration {
+	if step.Timeout > 0 {
+		return step.Timeout
+	}
+
+	return m.defaultTimeout
+}
+
+func (m *Manager) definition(name string) (Definition, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	definition, ok := m.definitions[name]
+	if !ok {
+		return Definition{}, NewDefinitionNotFound(name)
+	}
+
+	return definition, nil
+}
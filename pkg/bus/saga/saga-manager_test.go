@@ -0,0 +1,186 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/saga"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stepCommand struct {
+	Step string
+}
+
+type undoCommand struct {
+	Step string
+}
+
+type recordingHandler struct {
+	lock  *sync.Mutex
+	calls *[]string
+	fail  map[string]error
+	delay map[string]time.Duration
+}
+
+func (h recordingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	var call string
+	switch c := c.(type) {
+	case *stepCommand:
+		call = "do " + c.Step
+	case *undoCommand:
+		call = "undo " + c.Step
+	}
+
+	if delay := h.delay[call]; delay > 0 {
+		<-time.After(delay)
+	}
+
+	h.lock.Lock()
+	*h.calls = append(*h.calls, call)
+	h.lock.Unlock()
+
+	return h.fail[call]
+}
+
+func step(name string, compensated bool) saga.Step {
+	s := saga.Step{
+		Name:   name,
+		Action: func(s *saga.Saga) bus.Dto { return &stepCommand{Step: name + ":" + s.Data["user"]} },
+	}
+	if compensated {
+		s.Compensation = func(s *saga.Saga) bus.Dto { return &undoCommand{Step: name + ":" + s.Data["user"]} }
+	}
+
+	return s
+}
+
+func newManager(t *testing.T, store saga.Store, h recordingHandler) *saga.Manager {
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&stepCommand{}, h))
+	require.NoError(t, cb.RegisterCommand(&undoCommand{}, h))
+
+	m := saga.NewManager(store, cb, shared_image_infrastructure.NewZerologAdapter(), time.Second, 10*time.Millisecond)
+	require.NoError(t, m.Register(saga.Definition{
+		Name:  "sign-up",
+		Steps: []saga.Step{step("create", true), step("settings", true), step("email", false)},
+	}))
+
+	return m
+}
+
+func TestManager_Start_RunsEveryStep(t *testing.T) {
+	var calls []string
+	m := newManager(t, saga.NewInMemoryStore(), recordingHandler{lock: &sync.Mutex{}, calls: &calls})
+
+	s, err := m.Start(context.Background(), "sign-up", "u1", map[string]string{"user": "u1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, saga.StatusCompleted, s.Status)
+	assert.Equal(t, []string{"do create:u1", "do settings:u1", "do email:u1"}, calls)
+}
+
+func TestManager_Start_CompensatesStepsRunBeforeTheFailingOne(t *testing.T) {
+	var calls []string
+	store := saga.NewInMemoryStore()
+	m := newManager(t, store, recordingHandler{
+		lock:  &sync.Mutex{},
+		calls: &calls,
+		fail:  map[string]error{"do email:u1": errors.New("smtp down")},
+	})
+
+	_, err := m.Start(context.Background(), "sign-up", "u1", map[string]string{"user": "u1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"do create:u1", "do settings:u1", "do email:u1", "undo settings:u1", "undo create:u1"}, calls)
+	s, err := store.Find(context.Background(), "sign-up", "u1")
+	require.NoError(t, err)
+	assert.Equal(t, saga.StatusCompensated, s.Status)
+	assert.Equal(t, "smtp down", s.Error)
+}
+
+func TestManager_Start_CompensatesTimedOutStep(t *testing.T) {
+	var calls []string
+	m := newManager(t, saga.NewInMemoryStore(), recordingHandler{
+		lock:  &sync.Mutex{},
+		calls: &calls,
+		delay: map[string]time.Duration{"do settings": 200 * time.Millisecond},
+	})
+	require.NoError(t, m.Register(saga.Definition{
+		Name: "slow",
+		Steps: []saga.Step{
+			step("create", true),
+			{
+				Name:         "settings",
+				Action:       func(s *saga.Saga) bus.Dto { return &stepCommand{Step: "settings"} },
+				Compensation: func(s *saga.Saga) bus.Dto { return &undoCommand{Step: "settings"} },
+				Timeout:      50 * time.Millisecond,
+			},
+		},
+	}))
+
+	s, err := m.Start(context.Background(), "slow", "u1", map[string]string{"user": "u1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, saga.StatusCompensated, s.Status)
+	assert.Equal(t, []string{"do create:u1", "undo settings", "undo create:u1"}, calls)
+}
+
+func TestManager_Start_FailsWhenCompensationFails(t *testing.T) {
+	var calls []string
+	m := newManager(t, saga.NewInMemoryStore(), recordingHandler{
+		lock:  &sync.Mutex{},
+		calls: &calls,
+		fail: map[string]error{
+			"do settings:u1": errors.New("settings down"),
+			"undo create:u1": errors.New("database down"),
+		},
+	})
+
+	s, err := m.Start(context.Background(), "sign-up", "u1", map[string]string{"user": "u1"})
+
+	assert.Error(t, err)
+	assert.Equal(t, saga.StatusFailed, s.Status)
+}
+
+func TestManager_Start_FailsOnDuplicatedCorrelationId(t *testing.T) {
+	var calls []string
+	m := newManager(t, saga.NewInMemoryStore(), recordingHandler{lock: &sync.Mutex{}, calls: &calls})
+	_, err := m.Start(context.Background(), "sign-up", "u1", map[string]string{"user": "u1"})
+	require.NoError(t, err)
+
+	_, err = m.Start(context.Background(), "sign-up", "u1", map[string]string{"user": "u1"})
+
+	assert.IsType(t, saga.SagaAlreadyStarted{}, err)
+}
+
+func TestManager_Run_CompensatesAbandonedSagas(t *testing.T) {
+	var calls []string
+	store := saga.NewInMemoryStore()
+	m := newManager(t, store, recordingHandler{lock: &sync.Mutex{}, calls: &calls})
+	require.NoError(t, store.Create(context.Background(), &saga.Saga{
+		Name:          "sign-up",
+		CorrelationId: "u1",
+		Status:        saga.StatusRunning,
+		Step:          1,
+		Data:          map[string]string{"user": "u1"},
+		Deadline:      time.Now().Add(-time.Second),
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		s, err := store.Find(context.Background(), "sign-up", "u1")
+		return err == nil && s.Status == saga.StatusCompensated
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"undo settings:u1", "undo create:u1"}, calls)
+}
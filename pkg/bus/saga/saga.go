@@ -0,0 +1,188 @@
+package saga
+
+import (
+	"context"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Definition is a workflow run step by step by the Manager, undone in reverse order through the
+// compensations of the steps already run once one of them fails.
+type Definition struct {
+	Name  string
+	Steps []Step
+}
+
+type Step struct {
+	Name string
+	// Action builds the command dispatched to run the step.
+	Action func(s *Saga) bus.Dto
+	// Compensation builds the command undoing the step, nil when there is nothing to undo.
+	// Compensations may be dispatched more than once and must be idempotent.
+	Compensation func(s *Saga) bus.Dto
+	// Timeout bounds the dispatch of the step commands, zero uses the default of the Manager.
+	Timeout time.Duration
+}
+
+type Status string
+
+const (
+	StatusRunning      Status = "running"
+	StatusCompleted    Status = "completed"
+	StatusCompensating Status = "compensating"
+	StatusCompensated  Status = "compensated"
+	// StatusFailed is a saga whose compensation failed, left for manual intervention.
+	StatusFailed Status = "failed"
+)
+
+// Saga is a running or finished instance of a Definition, one per correlation id.
+type Saga struct {
+	Name          string
+	CorrelationId string
+	Status        Status
+	// Step is the index of the step running or being compensated.
+	Step int
+	Data map[string]string
+	// Error is the failure the saga is compensating.
+	Error string
+	// Deadline is when the saga is considered abandoned by the instance running it.
+	Deadline  time.Time
+	StartedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (s *Saga) finished() bool {
+	return s.Status == StatusCompleted || s.Status == StatusCompensated || s.Status == StatusFailed
+}
+
+type Store interface {
+	// Create saves a new saga, failing with SagaAlreadyStarted when its correlation id is taken.
+	Create(ctx context.Context, s *Saga) error
+	Save(ctx context.Context, s *Saga) error
+	Find(ctx context.Context, name string, correlationId string) (*Saga, error)
+	// ClaimStale returns the unfinished sagas past their deadline, moving it lease ahead so other
+	// instances do not claim them too.
+	ClaimStale(ctx context.Context, now time.Time, lease time.Duration) ([]*Saga, error)
+}
+
+type SagaAlreadyStarted struct {
+	message       string
+	name          string
+	correlationId string
+}
+
+func (s SagaAlreadyStarted) Error() string {
+	return s.message
+}
+
+func NewSagaAlreadyStarted(name string, correlationId string) SagaAlreadyStarted {
+	return SagaAlreadyStarted{message: "Saga already started", name: name, correlationId: correlationId}
+}
+
+type SagaNotFound struct {
+	message       string
+	name          string
+	correlationId string
+}
+
+func (s SagaNotFound) Error() string {
+	return s.message
+}
+
+func NewSagaNotFound(name string, correlationId string) SagaNotFound {
+	return SagaNotFound{message: "Saga not found", name: name, correlationId: correlationId}
+}
+
+type DefinitionNotFound struct {
+	message string
+	name    string
+}
+
+func (d DefinitionNotFound) Error() string {
+	return d.message
+}
+
+func NewDefinitionNotFound(name string) DefinitionNotFound {
+	return DefinitionNotFound{message: "Saga definition not found: " + name, name: name}
+}
+
+// InMemoryStore is a Store that does not survive restarts nor is shared between instances, meant
+// for tests and local runs.
+type InMemoryStore struct {
+	sagas map[string]Saga
+	lock  sync.Mutex
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{sagas: make(map[string]Saga)}
+}
+
+func (s *InMemoryStore) Create(ctx context.Context, saga *Saga) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.sagas[storeKey(saga.Name, saga.CorrelationId)]; ok {
+		return NewSagaAlreadyStarted(saga.Name, saga.CorrelationId)
+	}
+	s.sagas[storeKey(saga.Name, saga.CorrelationId)] = copySaga(saga)
+
+	return nil
+}
+
+func (s *InMemoryStore) Save(ctx context.Context, saga *Saga) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sagas[storeKey(saga.Name, saga.CorrelationId)] = copySaga(saga)
+
+	return nil
+}
+
+func (s *InMemoryStore) Find(ctx context.Context, name string, correlationId string) (*Saga, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	saga, ok := s.sagas[storeKey(name, correlationId)]
+	if !ok {
+		return nil, NewSagaNotFound(name, correlationId)
+	}
+	found := copySaga(&saga)
+
+	return &found, nil
+}
+
+func (s *InMemoryStore) ClaimStale(ctx context.Context, now time.Time, lease time.Duration) ([]*Saga, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var stale []*Saga
+	for key, saga := range s.sagas {
+		if saga.finished() || !saga.Deadline.Before(now) {
+			continue
+		}
+		saga.Deadline = now.Add(lease)
+		s.sagas[key] = saga
+
+		claimed := copySaga(&saga)
+		stale = append(stale, &claimed)
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].StartedAt.Before(stale[j].StartedAt) })
+
+	return stale, nil
+}
+
+func storeKey(name string, correlationId string) string {
+	return name + "/" + correlationId
+}
+
+func copySaga(s *Saga) Saga {
+	c := *s
+	c.Data = make(map[string]string, len(s.Data))
+	for k, v := range s.Data {
+		c.Data[k] = v
+	}
+
+	return c
+}
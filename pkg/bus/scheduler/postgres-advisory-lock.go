@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"gorm.io/gorm"
+	"hash/fnv"
+)
+
+// PostgresAdvisoryLock is a Lock held through a session level advisory lock. The lock lives as long
+// as the connection taking it, so it is released if the instance holding it dies.
+type PostgresAdvisoryLock struct {
+	db   *gorm.DB
+	key  int64
+	conn *sql.Conn
+}
+
+// NewPostgresAdvisoryLock returns the lock identified by name, every replica must use the same name.
+func NewPostgresAdvisoryLock(db *gorm.DB, name string) *PostgresAdvisoryLock {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+
+	return &PostgresAdvisoryLock{db: db, key: int64(h.Sum64())}
+}
+
+func (p *PostgresAdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	if p.conn != nil {
+		if err := p.conn.PingContext(ctx); err != nil {
+			p.close()
+			return false, err
+		}
+		return true, nil
+	}
+
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", p.key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return false, err
+	}
+	if !acquired {
+		return false, conn.Close()
+	}
+	p.conn = conn
+
+	return true, nil
+}
+
+func (p *PostgresAdvisoryLock) Release(ctx context.Context) error {
+	if p.conn == nil {
+		return nil
+	}
+	defer p.close()
+
+	_, err := p.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", p.key)
+
+	return err
+}
+
+func (p *PostgresAdvisoryLock) close() {
+	_ = p.conn.Close()
+	p.conn = nil
+}
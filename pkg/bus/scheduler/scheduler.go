@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/robfig/cron/v3"
+	"sync"
+	"time"
+)
+
+// Job dispatches Command on every occurrence of Spec, a standard cron expression ("0 3 * * *") or
+// a descriptor such as "@hourly" or "@every 10m".
+type Job struct {
+	Name    string
+	Spec    string
+	Command bus.Dto
+}
+
+// Lock elects the instance firing the jobs among every replica running a Scheduler.
+type Lock interface {
+	// TryAcquire takes the lock unless another instance holds it, reporting whether it is held.
+	// Called while held, it checks the lock was not lost meanwhile.
+	TryAcquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	Job
+	schedule cron.Schedule
+	next     time.Time
+}
+
+// Scheduler dispatches the commands of recurring jobs asynchronously. Only the instance holding
+// the Lock fires them, occurrences due while no instance holds it are skipped.
+type Scheduler struct {
+	cb           command.Bus
+	lock         Lock
+	l            shared_image_infrastructure.Logger
+	pollInterval time.Duration
+	jobs         []*scheduledJob
+	jobsLock     sync.Mutex
+}
+
+func NewScheduler(cb command.Bus, lock Lock, l shared_image_infrastructure.Logger, pollInterval time.Duration) *Scheduler {
+	return &Scheduler{cb: cb, lock: lock, l: l, pollInterval: pollInterval}
+}
+
+func (s *Scheduler) Register(job Job) error {
+	schedule, err := cron.ParseStandard(job.Spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of job %s: %w", job.Name, err)
+	}
+
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+
+	for _, scheduled := range s.jobs {
+		if scheduled.Name == job.Name {
+			return fmt.Errorf("job %s already registered", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &scheduledJob{Job: job, schedule: schedule})
+
+	return nil
+}
+
+// Run fires the due jobs while this instance holds the lock, until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer func() {
+		if err := s.lock.Release(context.WithoutCancel(ctx)); err != nil {
+			s.l.Error(ctx, "error releasing scheduler lock", map[string]interface{}{"error": err.Error()})
+		}
+	}()
+
+	leader := false
+	for {
+		held, err := s.lock.TryAcquire(ctx)
+		if err != nil {
+			s.l.Error(ctx, "error acquiring scheduler lock", map[string]interface{}{"error": err.Error()})
+		}
+
+		if held {
+			// A new leader schedules from now on, what the previous one was due to fire is skipped.
+			s.fireDue(ctx, time.Now(), !leader)
+		}
+		leader = held
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+func (s *Scheduler) fireDue(ctx context.Context, now time.Time, reschedule bool) {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+
+	for _, job := range s.jobs {
+		if reschedule || job.next.IsZero() {
+			job.next = job.schedule.Next(now)
+			continue
+		}
+		if job.next.After(now) {
+			continue
+		}
+
+		if err := s.cb.DispatchAsync(ctx, job.Command); err != nil {
+			s.l.Error(ctx, "error dispatching scheduled job", map[string]interface{}{"error": err.Error(), "job": job.Name})
+		}
+		job.next = job.schedule.Next(now)
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/bus/scheduler"
+	shared_image_infrastructure "github.com/mik3lon/starter-template/pkg/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type purgeCommand struct{}
+
+type countingHandler struct {
+	calls *atomic.Int32
+}
+
+func (h countingHandler) Handle(ctx context.Context, c bus.Dto) error {
+	h.calls.Add(1)
+	return nil
+}
+
+type fixedLock struct {
+	held bool
+}
+
+func (l fixedLock) TryAcquire(ctx context.Context) (bool, error) {
+	return l.held, nil
+}
+
+func (l fixedLock) Release(ctx context.Context) error {
+	return nil
+}
+
+func runScheduler(t *testing.T, lock scheduler.Lock) *atomic.Int32 {
+	calls := &atomic.Int32{}
+	cb := command.InitCommandBus(shared_image_infrastructure.NewZerologAdapter())
+	require.NoError(t, cb.RegisterCommand(&purgeCommand{}, countingHandler{calls: calls}))
+
+	s := scheduler.NewScheduler(cb, lock, shared_image_infrastructure.NewZerologAdapter(), 10*time.Millisecond)
+	require.NoError(t, s.Register(scheduler.Job{Name: "purge", Spec: "@every 1s", Command: &purgeCommand{}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.Run(ctx)
+
+	return calls
+}
+
+func TestScheduler_Run_FiresDueJobsWhileHoldingTheLock(t *testing.T) {
+	calls := runScheduler(t, fixedLock{held: true})
+
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_Run_DoesNotFireWithoutTheLock(t *testing.T) {
+	calls := runScheduler(t, fixedLock{held: false})
+
+	time.Sleep(1500 * time.Millisecond)
+	assert.Zero(t, calls.Load())
+}
+
+func TestScheduler_Register_FailsOnInvalidSpec(t *testing.T) {
+	s := scheduler.NewScheduler(nil, fixedLock{}, shared_image_infrastructure.NewZerologAdapter(), time.Second)
+
+	assert.Error(t, s.Register(scheduler.Job{Name: "purge", Spec: "every day", Command: &purgeCommand{}}))
+}
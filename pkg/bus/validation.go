@@ -0,0 +1,146 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ValidationMiddlewareName is the name the validation middlewares are expected to be used with.
+const ValidationMiddlewareName = "validation"
+
+// Validatable is a Dto checking rules its `validate` struct tags can not express, Validate returns
+// a ValidationError or nil.
+type Validatable interface {
+	Validate() error
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	message string
+	Fields  []FieldError
+}
+
+func (v ValidationError) Error() string {
+	return v.message
+}
+
+func (v ValidationError) Kind() ErrorKind {
+	return KindValidation
+}
+
+func NewValidationError(fields ...FieldError) ValidationError {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+
+	return ValidationError{message: "Validation failed: " + strings.Join(messages, ", "), Fields: fields}
+}
+
+var structValidator = newStructValidator()
+
+func newStructValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return snakeCase(field.Name)
+		}
+		return name
+	})
+
+	return v
+}
+
+// Validate checks the `validate` struct tags of dto, then its Validatable rules, joining every
+// failing field into a single ValidationError.
+func Validate(dto Dto) error {
+	var fields []FieldError
+
+	value := reflect.ValueOf(dto)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		err := structValidator.Struct(dto)
+
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			for _, validationErr := range validationErrs {
+				fields = append(fields, FieldError{Field: fieldPath(validationErr), Message: fieldMessage(validationErr)})
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if validatable, ok := dto.(Validatable); ok {
+		err := validatable.Validate()
+
+		var validationErr ValidationError
+		if errors.As(err, &validationErr) {
+			fields = append(fields, validationErr.Fields...)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return NewValidationError(fields...)
+}
+
+// fieldPath drops the struct name validator prefixes the namespace with.
+func fieldPath(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "min":
+		return fmt.Sprintf("must be at least %s long", err.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s long", err.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", err.Param())
+	default:
+		return fmt.Sprintf("is not valid (%s)", err.Tag())
+	}
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(name[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package bus_test
+
+import (
+	"testing"
+
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signUpDto struct {
+	Email         string `validate:"required,email"`
+	PlainPassword string `validate:"required_if=IsSocial false"`
+	IsSocial      bool
+}
+
+type rangeDto struct {
+	From int
+	To   int
+}
+
+func (d rangeDto) Validate() error {
+	if d.From > d.To {
+		return bus.NewValidationError(bus.FieldError{Field: "from", Message: "must not be after to"})
+	}
+
+	return nil
+}
+
+func TestValidate_ReturnsEveryInvalidField(t *testing.T) {
+	err := bus.Validate(&signUpDto{Email: "not-an-email"})
+
+	var validationErr bus.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []bus.FieldError{
+		{Field: "email", Message: "must be a valid email"},
+		{Field: "plain_password", Message: "is required"},
+	}, validationErr.Fields)
+}
+
+func TestValidate_AppliesConditionalRules(t *testing.T) {
+	assert.NoError(t, bus.Validate(&signUpDto{Email: "john@doe.com", IsSocial: true}))
+}
+
+func TestValidate_RunsValidatable(t *testing.T) {
+	err := bus.Validate(&rangeDto{From: 2, To: 1})
+
+	var validationErr bus.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []bus.FieldError{{Field: "from", Message: "must not be after to"}}, validationErr.Fields)
+	assert.NoError(t, bus.Validate(&rangeDto{From: 1, To: 2}))
+}
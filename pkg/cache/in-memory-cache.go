@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// InMemoryLRUCache keeps up to capacity entries, evicting the least recently used one when full.
+type InMemoryLRUCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+	lock     sync.Mutex
+}
+
+func NewInMemoryLRUCache(capacity int) *InMemoryLRUCache {
+	return &InMemoryLRUCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *InMemoryLRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := element.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+
+	return e.value, true, nil
+}
+
+func (c *InMemoryLRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = &entry{key: key, value: value, expiresAt: time.Now().Add(ttl)}
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+func (c *InMemoryLRUCache) Delete(ctx context.Context, keys ...string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+
+	return nil
+}
+
+func (c *InMemoryLRUCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
@@ -0,0 +1,50 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewInMemoryLRUCache(2)
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("b"), time.Minute))
+	_, _, _ = c.Get(ctx, "a")
+
+	require.NoError(t, c.Set(ctx, "c", []byte("c"), time.Minute))
+
+	_, ok, _ := c.Get(ctx, "b")
+	assert.False(t, ok)
+	value, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), value)
+}
+
+func TestInMemoryLRUCache_ExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewInMemoryLRUCache(2)
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Millisecond))
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestInMemoryLRUCache_Delete(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewInMemoryLRUCache(2)
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Minute))
+
+	require.NoError(t, c.Delete(ctx, "a", "missing"))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+}
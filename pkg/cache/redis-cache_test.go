@@ -0,0 +1,44 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mik3lon/starter-template/pkg/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisCache_SetGetAndDelete(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c := cache.NewRedisCache(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Minute))
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), value)
+	assert.True(t, server.Exists("test:a"))
+
+	require.NoError(t, c.Delete(ctx, "a"))
+	_, ok, err = c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedisCache_ExpiresEntries(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	c := cache.NewRedisCache(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:")
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Minute))
+
+	server.FastForward(2 * time.Minute)
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,12 +17,86 @@ type Config struct {
 	GoogleClientId     string
 	PrivateKeyPEM      string
 	PrivateKeyPassword string
-	PublicKeyPEM       string
+	// PrivateKeyId names the PrivateKeyPEM key in the `kid` header, defaults to the thumbprint of the key.
+	PrivateKeyId string
+	// JwtKeysDir, when set, replaces PrivateKeyPEM with a `<kid>.pem` file per key: tokens are signed with
+	// JwtActiveKeyId and verified with any of them. Retired keys must stay until their tokens expire.
+	JwtKeysDir     string
+	JwtActiveKeyId string
+	// JwtIssuer is the `iss` claim of the tokens and the issuer of the OpenID configuration, it is required.
+	JwtIssuer string
 
 	S3Region      string
 	S3Endpoint    string
 	S3ImageBucket string
 	AppEnv        string
+
+	CommandQueueWorkers      int
+	CommandQueuePollInterval time.Duration
+
+	OutboxBatchSize    int
+	OutboxPollInterval time.Duration
+	// OutboxMaxAttempts parks a message as dead once relaying it failed that many times.
+	OutboxMaxAttempts int
+
+	// EventsTopic enables the SNS/SQS event transport when set, otherwise events stay in process.
+	EventsTopic             string
+	EventsQueue             string
+	EventsDeadLetterQueue   string
+	EventsMaxReceiveCount   int
+	EventsVisibilityTimeout time.Duration
+	EventsEndpoint          string
+
+	// CacheBackend is either "memory" or "redis".
+	CacheBackend  string
+	CacheSize     int
+	RedisAddr     string
+	QueryCacheTTL time.Duration
+
+	// CommandTimeout and QueryTimeout bound every handler, zero disables them.
+	CommandTimeout     time.Duration
+	QueryTimeout       time.Duration
+	ImageUploadTimeout time.Duration
+
+	// IdempotencyBackend is either "postgres" or "redis".
+	IdempotencyBackend string
+	IdempotencyTTL     time.Duration
+	// IdempotencyClaimTTL bounds how long a command holds its idempotency key, and duplicates wait for it.
+	// It should outlast CommandTimeout, zero falls back to command.DefaultIdempotencyClaimTTL.
+	IdempotencyClaimTTL time.Duration
+
+	// SagaStepTimeout bounds the steps of sagas not setting their own timeout.
+	SagaStepTimeout  time.Duration
+	SagaPollInterval time.Duration
+
+	SchedulerPollInterval time.Duration
+
+	// RequireEmailVerification refuses password sign ins until the user verifies its email.
+	RequireEmailVerification bool
+	EmailVerificationTTL     time.Duration
+
+	PasswordResetTTL time.Duration
+
+	// MailerBackend is either "smtp" or "memory", the latter only records the messages.
+	MailerBackend     string
+	SMTPHost          string
+	SMTPPort          int
+	SMTPUsername      string
+	SMTPPassword      string
+	MailFrom          string
+	MailDefaultLocale string
+	// EmailVerificationUrl and PasswordResetUrl are the front end pages the emails link to with a token.
+	EmailVerificationUrl string
+	PasswordResetUrl     string
+
+	// RolePermissions lists the permissions of each role as `role=permission,permission;role=...`.
+	// RolePermissionsBackend is either "config" or "postgres", the latter seeded with RolePermissions.
+	RolePermissions        string
+	RolePermissionsBackend string
+
+	// TokenRevocationBackend is either "redis" or "memory", revocations only reach every instance with redis.
+	// The memory backend is used, and logged, when redis can not be reached on startup.
+	TokenRevocationBackend string
 }
 
 // LoadConfig loads environment variables from a .env file and populates the Config struct.
@@ -52,11 +128,66 @@ func load() *Config {
 		GoogleClientId:     getEnv("GOOGLE_CLIENT_ID", ""),
 		PrivateKeyPEM:      getEnv("USER_PRIVATE_PEM_FILE", ""),
 		PrivateKeyPassword: getEnv("USER_PRIVATE_PEM_PASSWORD", ""),
-		PublicKeyPEM:       getEnv("USER_PUBLIC_PEM_FILE", ""),
+		PrivateKeyId:       getEnv("USER_PRIVATE_PEM_KEY_ID", ""),
+		JwtKeysDir:         getEnv("JWT_KEYS_DIR", ""),
+		JwtActiveKeyId:     getEnv("JWT_ACTIVE_KEY_ID", ""),
+		JwtIssuer:          getEnv("JWT_ISSUER", ""),
 		S3Endpoint:         getEnv("AWS_S3_ENDPOINT", ""),
 		S3Region:           getEnv("AWS_S3_REGION", "us-east-1"),
 		S3ImageBucket:      getEnv("AWS_S3_IMAGE_BUCKET", ""),
 		AppEnv:             getEnv("APP_ENV", "test"),
+
+		CommandQueueWorkers:      getEnvInt("COMMAND_QUEUE_WORKERS", 4),
+		CommandQueuePollInterval: getEnvDuration("COMMAND_QUEUE_POLL_INTERVAL", time.Second),
+
+		OutboxBatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxMaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+
+		EventsTopic:             getEnv("AWS_SNS_EVENTS_TOPIC", ""),
+		EventsQueue:             getEnv("AWS_SQS_EVENTS_QUEUE", ""),
+		EventsDeadLetterQueue:   getEnv("AWS_SQS_EVENTS_DEAD_LETTER_QUEUE", ""),
+		EventsMaxReceiveCount:   getEnvInt("AWS_SQS_EVENTS_MAX_RECEIVE_COUNT", 5),
+		EventsVisibilityTimeout: getEnvDuration("AWS_SQS_EVENTS_VISIBILITY_TIMEOUT", 30*time.Second),
+		EventsEndpoint:          getEnv("AWS_EVENTS_ENDPOINT", ""),
+
+		CacheBackend:  getEnv("CACHE_BACKEND", "memory"),
+		CacheSize:     getEnvInt("CACHE_SIZE", 1000),
+		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
+		QueryCacheTTL: getEnvDuration("QUERY_CACHE_TTL", 5*time.Minute),
+
+		CommandTimeout:     getEnvDuration("COMMAND_TIMEOUT", 30*time.Second),
+		QueryTimeout:       getEnvDuration("QUERY_TIMEOUT", 10*time.Second),
+		ImageUploadTimeout: getEnvDuration("IMAGE_UPLOAD_TIMEOUT", 20*time.Second),
+
+		IdempotencyBackend:  getEnv("IDEMPOTENCY_BACKEND", "postgres"),
+		IdempotencyTTL:      getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyClaimTTL: getEnvDuration("IDEMPOTENCY_CLAIM_TTL", time.Minute),
+
+		SagaStepTimeout:  getEnvDuration("SAGA_STEP_TIMEOUT", 30*time.Second),
+		SagaPollInterval: getEnvDuration("SAGA_POLL_INTERVAL", 10*time.Second),
+
+		SchedulerPollInterval: getEnvDuration("SCHEDULER_POLL_INTERVAL", time.Second),
+
+		RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationTTL:     getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+
+		PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		MailerBackend:        getEnv("MAILER_BACKEND", "smtp"),
+		SMTPHost:             getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:             getEnvInt("SMTP_PORT", 1025),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		MailFrom:             getEnv("MAIL_FROM", "no-reply@starter-template.local"),
+		MailDefaultLocale:    getEnv("MAIL_DEFAULT_LOCALE", "en"),
+		EmailVerificationUrl: getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"),
+		PasswordResetUrl:     getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+
+		RolePermissions:        getEnv("ROLE_PERMISSIONS", "user=profile:read,profile:write;admin=*"),
+		RolePermissionsBackend: getEnv("ROLE_PERMISSIONS_BACKEND", "config"),
+
+		TokenRevocationBackend: getEnv("TOKEN_REVOCATION_BACKEND", "redis"),
 	}
 }
 
@@ -67,3 +198,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt gets an integer environment variable or returns a default value if not set or invalid.
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable (e.g. "true") or returns a default value if not set or invalid.
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "1s") or returns a default value if not set or invalid.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -1,46 +1,130 @@
 package middleware
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/auth"
 	"net/http"
 	"strings"
 )
 
 type AuthMiddleware struct {
-	ur user_domain.UserRepository
-	ue user_domain.UserEncoder
+	ur  user_domain.UserRepository
+	ue  user_domain.UserEncoder
+	rts user_domain.TokenRevocationStore
+	rpr user_domain.RolePermissionRepository
 }
 
-func NewAuthMiddleware(ur user_domain.UserRepository, ue user_domain.UserEncoder) *AuthMiddleware {
-	return &AuthMiddleware{ur: ur, ue: ue}
+func NewAuthMiddleware(
+	ur user_domain.UserRepository,
+	ue user_domain.UserEncoder,
+	rts user_domain.TokenRevocationStore,
+	rpr user_domain.RolePermissionRepository,
+) *AuthMiddleware {
+	return &AuthMiddleware{ur: ur, ue: ue, rts: rts, rpr: rpr}
 }
 
-// Check ensures that the user is authenticated
+// authError is a request failing authentication, with the status it is answered with.
+type authError struct {
+	status  int
+	message string
+}
+
+func unauthorized(message string) *authError {
+	return &authError{status: http.StatusUnauthorized, message: message}
+}
+
+// Check ensures that the user is authenticated, putting its auth.Principal in the context of the request
+// so the handlers and the buses they dispatch to can read it.
 func (am *AuthMiddleware) Check() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
-			c.Abort()
+		principal, authErr := am.authenticate(c, c.GetHeader("Authorization"))
+		if authErr != nil {
+			c.AbortWithStatusJSON(authErr.status, gin.H{"error": authErr.message})
 			return
 		}
 
-		// Check if the header starts with "Bearer "
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
-			c.Abort()
-			return
-		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+	}
+}
 
-		claims, err := am.ue.DecryptToken(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		}
+func (am *AuthMiddleware) authenticate(ctx context.Context, authHeader string) (*auth.Principal, *authError) {
+	if authHeader == "" {
+		return nil, unauthorized("Authorization header missing")
+	}
+
+	// Check if the header starts with "Bearer "
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return nil, unauthorized("Invalid Authorization header format")
+	}
+
+	claims, err := am.ue.DecryptToken(tokenString)
+	if err != nil {
+		return nil, unauthorized(err.Error())
+	}
+
+	// Refresh tokens are only accepted by the refresh endpoint.
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok || mapClaims[user_domain.TokenTypeClaim] != user_domain.AccessTokenType {
+		return nil, unauthorized("Invalid access token")
+	}
+
+	token, err := user_domain.NewIssuedTokenFromClaims(mapClaims)
+	if err != nil {
+		return nil, unauthorized("Invalid access token")
+	}
+
+	revoked, err := am.rts.IsRevoked(ctx, token)
+	if err != nil {
+		return nil, &authError{status: http.StatusServiceUnavailable, message: "Could not check the access token"}
+	}
+	if revoked {
+		return nil, unauthorized("Access token revoked")
+	}
+
+	// Tokens issued before roles were claims are the ones of regular users.
+	role, err := user_domain.NewRole(stringClaim(mapClaims, user_domain.RoleClaim))
+	if err != nil {
+		return nil, unauthorized("Invalid access token")
+	}
 
-		mapClaims := claims.(jwt.MapClaims)
-		c.Set("user_email", mapClaims["sub"])
+	permissions, err := am.rpr.PermissionsOf(ctx, role)
+	if err != nil {
+		return nil, &authError{status: http.StatusServiceUnavailable, message: "Could not check the permissions"}
 	}
+
+	return &auth.Principal{
+		UserId:         stringClaim(mapClaims, user_domain.UserIdClaim),
+		Email:          token.UserEmail,
+		Roles:          []user_domain.Role{role},
+		TokenId:        token.ID,
+		TokenExpiresAt: token.ExpiresAt,
+		AuthMethod:     auth.AuthMethodBearer,
+		Scopes:         permissions,
+	}, nil
+}
+
+// RequirePermission ensures that the principal is granted permission, it must follow Check.
+func (am *AuthMiddleware) RequirePermission(permission user_domain.Permission) func() gin.HandlerFunc {
+	return func() gin.HandlerFunc {
+		return func(c *gin.Context) {
+			principal, ok := auth.PrincipalFrom(c)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+				return
+			}
+			if !principal.Can(permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": auth.NewForbidden(permission).Error()})
+				return
+			}
+		}
+	}
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
 }
@@ -0,0 +1,129 @@
+package middleware_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	user_infrastructure "github.com/mik3lon/starter-template/internal/app/module/user/infrastructure"
+	"github.com/mik3lon/starter-template/pkg/auth"
+	"github.com/mik3lon/starter-template/pkg/http/middleware"
+	"github.com/mik3lon/starter-template/pkg/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEncoder(t *testing.T) *auth.JWTUserEncoder {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	key, err := auth.ParseKey("key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), "")
+	require.NoError(t, err)
+	ring, err := auth.NewKeyRing(key)
+	require.NoError(t, err)
+
+	return auth.NewJWTUserEncoder(ring, "https://users.example.com")
+}
+
+func TestAuthMiddleware_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ue := newEncoder(t)
+	am := middleware.NewAuthMiddleware(
+		nil,
+		ue,
+		user_infrastructure.NewInMemoryTokenRevocationStore(),
+		user_infrastructure.NewConfigRolePermissionRepository(map[user_domain.Role][]user_domain.Permission{
+			user_domain.RoleUser:  {user_domain.PermissionProfileRead},
+			user_domain.RoleAdmin: {"bus:*"},
+		}),
+	)
+
+	r := router.NewGinRouter()
+	r.Handle(http.MethodGet, "/admin", func(g *gin.Context) {
+		g.Status(http.StatusNoContent)
+	}, am.Check, am.RequirePermission(user_domain.PermissionBusRead))
+
+	cases := map[string]struct {
+		role   user_domain.Role
+		status int
+	}{
+		"granted through a wildcard": {user_domain.RoleAdmin, http.StatusNoContent},
+		"not granted":                {user_domain.RoleUser, http.StatusForbidden},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			token, err := ue.GenerateToken(&user_domain.User{ID: "user-id", Email: "johndoe@example.com", Role: c.role})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+			w := httptest.NewRecorder()
+			r.Handler().ServeHTTP(w, req)
+
+			assert.Equal(t, c.status, w.Code)
+		})
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAuthMiddleware_Check_PutsPrincipalInContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ue := newEncoder(t)
+	am := middleware.NewAuthMiddleware(
+		nil,
+		ue,
+		user_infrastructure.NewInMemoryTokenRevocationStore(),
+		user_infrastructure.NewConfigRolePermissionRepository(map[user_domain.Role][]user_domain.Permission{
+			user_domain.RoleUser: {user_domain.PermissionProfileRead},
+		}),
+	)
+
+	var principal *auth.Principal
+	r := router.NewGinRouter()
+	r.Handle(http.MethodGet, "/me", func(g *gin.Context) {
+		principal, _ = auth.PrincipalFrom(g)
+		g.Status(http.StatusNoContent)
+	}, am.Check)
+
+	token, err := ue.GenerateToken(&user_domain.User{ID: "user-id", Email: "johndoe@example.com", Role: user_domain.RoleUser})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NotNil(t, principal)
+	assert.Equal(t, "user-id", principal.UserId)
+	assert.Equal(t, "johndoe@example.com", principal.Email)
+	assert.Equal(t, []user_domain.Role{user_domain.RoleUser}, principal.Roles)
+	assert.Equal(t, auth.AuthMethodBearer, principal.AuthMethod)
+	assert.NotEmpty(t, principal.TokenId)
+	assert.True(t, principal.Can(user_domain.PermissionProfileRead))
+
+	t.Run("invalid token", func(t *testing.T) {
+		principal = nil
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer not-a-token")
+		w := httptest.NewRecorder()
+		r.Handler().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Nil(t, principal)
+	})
+}
@@ -30,7 +30,7 @@ func (s *S3ImageUploader) Upload(ctx context.Context, fi file.FileInfo) (*file.U
 
 	acl := "public-read"
 	key := fmt.Sprintf("incidents/%s/images/%s", "test", fi.Filename)
-	_, err = s.s3Client.PutObject(&s3.PutObjectInput{
+	_, err = s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
 		Body:   bytes.NewReader(fi.Content),
@@ -40,6 +40,7 @@ func (s *S3ImageUploader) Upload(ctx context.Context, fi file.FileInfo) (*file.U
 		s.l.Error(ctx, "error uploading image", map[string]interface{}{
 			"error": err.Error(),
 		})
+		return nil, err
 	}
 
 	url := fmt.Sprintf("%s/%s/%s", s.s3Endpoint, s.bucket, key)
@@ -53,10 +54,11 @@ func (s *S3ImageUploader) Upload(ctx context.Context, fi file.FileInfo) (*file.U
 }
 
 func (s *S3ImageUploader) createBucketIfNotExists(ctx context.Context) (*s3.CreateBucketOutput, error) {
-	_, err := s.s3Client.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String(s.bucket)})
+	_, err := s.s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.bucket)})
 
 	if err != nil {
-		_, err := s.s3Client.CreateBucket(
+		_, err := s.s3Client.CreateBucketWithContext(
+			ctx,
 			&s3.CreateBucketInput{
 				Bucket: aws.String(s.bucket),
 			})
@@ -64,7 +66,7 @@ func (s *S3ImageUploader) createBucketIfNotExists(ctx context.Context) (*s3.Crea
 			s.l.Error(ctx, "error creating bucket", map[string]interface{}{"error": err.Error()})
 		}
 
-		err = s.s3Client.WaitUntilBucketExists(&s3.HeadBucketInput{
+		err = s.s3Client.WaitUntilBucketExistsWithContext(ctx, &s3.HeadBucketInput{
 			Bucket: aws.String(s.bucket),
 		})
 		if err != nil {
@@ -84,7 +86,7 @@ func (s *S3ImageUploader) createBucketIfNotExists(ctx context.Context) (*s3.Crea
         ]
     }`
 		policy := fmt.Sprintf(bucketPolicy, s.bucket)
-		_, err = s.s3Client.PutBucketPolicy(&s3.PutBucketPolicyInput{
+		_, err = s.s3Client.PutBucketPolicyWithContext(ctx, &s3.PutBucketPolicyInput{
 			Bucket: aws.String(s.bucket),
 			Policy: aws.String(policy),
 		})
@@ -0,0 +1,58 @@
+package shared_image_infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/mik3lon/starter-template/pkg/bus"
+)
+
+// EventNameAttribute is the message attribute carrying the event name, consumers use it to rebuild the event.
+const EventNameAttribute = "event_name"
+
+// SNSEventPublisher publishes events as JSON messages to an SNS topic.
+type SNSEventPublisher struct {
+	client   *sns.SNS
+	topicArn string
+	l        Logger
+}
+
+func NewSNSEventPublisher(c *sns.SNS, topicArn string, l Logger) *SNSEventPublisher {
+	return &SNSEventPublisher{client: c, topicArn: topicArn, l: l}
+}
+
+func (p *SNSEventPublisher) Publish(ctx context.Context, events ...bus.Dto) error {
+	for _, event := range events {
+		name := bus.NameOf(event)
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", name, err)
+		}
+
+		_, err = p.client.PublishWithContext(ctx, &sns.PublishInput{
+			TopicArn: aws.String(p.topicArn),
+			Message:  aws.String(string(payload)),
+			MessageAttributes: map[string]*sns.MessageAttributeValue{
+				EventNameAttribute: {DataType: aws.String("String"), StringValue: aws.String(name)},
+			},
+		})
+		if err != nil {
+			p.l.Error(ctx, "error publishing event", map[string]interface{}{"error": err.Error(), "event": name})
+			return fmt.Errorf("failed to publish event %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// EnsureTopic creates the topic if it does not exist and returns its arn.
+func EnsureTopic(ctx context.Context, c *sns.SNS, name string) (string, error) {
+	output, err := c.CreateTopicWithContext(ctx, &sns.CreateTopicInput{Name: aws.String(name)})
+	if err != nil {
+		return "", fmt.Errorf("failed to create topic %s: %w", name, err)
+	}
+
+	return *output.TopicArn, nil
+}
@@ -0,0 +1,164 @@
+package shared_image_infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/mik3lon/starter-template/pkg/bus"
+	"time"
+)
+
+// EventDispatcher receives the events consumed from the queue, usually the event bus.
+type EventDispatcher interface {
+	Publish(ctx context.Context, events ...bus.Dto) error
+}
+
+// SQSEventConsumer long-polls an SQS queue, rebuilds every message as the event named by its
+// EventNameAttribute and dispatches it. Messages whose dispatch fails are not deleted, so SQS
+// delivers them again until its redrive policy moves them to the dead letter queue.
+type SQSEventConsumer struct {
+	client            *sqs.SQS
+	queueUrl          string
+	registry          *bus.Registry
+	dispatcher        EventDispatcher
+	l                 Logger
+	visibilityTimeout time.Duration
+}
+
+func NewSQSEventConsumer(
+	c *sqs.SQS,
+	queueUrl string,
+	registry *bus.Registry,
+	dispatcher EventDispatcher,
+	l Logger,
+	visibilityTimeout time.Duration,
+) *SQSEventConsumer {
+	return &SQSEventConsumer{
+		client:            c,
+		queueUrl:          queueUrl,
+		registry:          registry,
+		dispatcher:        dispatcher,
+		l:                 l,
+		visibilityTimeout: visibilityTimeout,
+	}
+}
+
+// Run consumes the queue until ctx is done.
+func (c *SQSEventConsumer) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		output, err := c.client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(c.queueUrl),
+			MaxNumberOfMessages:   aws.Int64(10),
+			WaitTimeSeconds:       aws.Int64(20),
+			VisibilityTimeout:     aws.Int64(int64(c.visibilityTimeout.Seconds())),
+			AttributeNames:        []*string{aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount)},
+			MessageAttributeNames: []*string{aws.String(EventNameAttribute)},
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				c.l.Error(ctx, "error receiving messages", map[string]interface{}{"error": err.Error()})
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		c.consume(ctx, output.Messages)
+	}
+}
+
+func (c *SQSEventConsumer) consume(ctx context.Context, messages []*sqs.Message) {
+	if len(messages) == 0 {
+		return
+	}
+
+	// Handling the batch may take longer than the visibility timeout, keep the messages hidden meanwhile.
+	handleCtx, stopExtending := context.WithCancel(context.WithoutCancel(ctx))
+	go c.extendVisibility(handleCtx, messages)
+
+	var handled []*sqs.DeleteMessageBatchRequestEntry
+	for _, message := range messages {
+		if err := c.handle(handleCtx, message); err != nil {
+			c.l.Warn(ctx, "error handling message", map[string]interface{}{
+				"error":         err.Error(),
+				"message_id":    aws.StringValue(message.MessageId),
+				"receive_count": receiveCount(message),
+			})
+			continue
+		}
+
+		handled = append(handled, &sqs.DeleteMessageBatchRequestEntry{
+			Id:            message.MessageId,
+			ReceiptHandle: message.ReceiptHandle,
+		})
+	}
+	stopExtending()
+
+	c.delete(context.WithoutCancel(ctx), handled)
+}
+
+func (c *SQSEventConsumer) handle(ctx context.Context, message *sqs.Message) error {
+	attribute, ok := message.MessageAttributes[EventNameAttribute]
+	if !ok {
+		return errors.New("message without " + EventNameAttribute + " attribute")
+	}
+
+	event, err := c.registry.Decode(aws.StringValue(attribute.StringValue), []byte(aws.StringValue(message.Body)))
+	if err != nil {
+		return err
+	}
+
+	return c.dispatcher.Publish(ctx, event)
+}
+
+func (c *SQSEventConsumer) extendVisibility(ctx context.Context, messages []*sqs.Message) {
+	ticker := time.NewTicker(c.visibilityTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			entries := make([]*sqs.ChangeMessageVisibilityBatchRequestEntry, len(messages))
+			for i, message := range messages {
+				entries[i] = &sqs.ChangeMessageVisibilityBatchRequestEntry{
+					Id:                message.MessageId,
+					ReceiptHandle:     message.ReceiptHandle,
+					VisibilityTimeout: aws.Int64(int64(c.visibilityTimeout.Seconds())),
+				}
+			}
+
+			_, err := c.client.ChangeMessageVisibilityBatchWithContext(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+				QueueUrl: aws.String(c.queueUrl),
+				Entries:  entries,
+			})
+			if err != nil && ctx.Err() == nil {
+				c.l.Warn(ctx, "error extending messages visibility", map[string]interface{}{"error": err.Error()})
+			}
+		}
+	}
+}
+
+func (c *SQSEventConsumer) delete(ctx context.Context, entries []*sqs.DeleteMessageBatchRequestEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	output, err := c.client.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(c.queueUrl),
+		Entries:  entries,
+	})
+	if err != nil {
+		c.l.Error(ctx, "error deleting messages", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	for _, failed := range output.Failed {
+		c.l.Error(ctx, "error deleting message", map[string]interface{}{
+			"error":      fmt.Sprintf("%s: %s", aws.StringValue(failed.Code), aws.StringValue(failed.Message)),
+			"message_id": aws.StringValue(failed.Id),
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package shared_image_infrastructure
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"strconv"
+)
+
+// EnsureQueue creates the queue and its dead letter queue if they do not exist. Messages
+// received more than maxReceiveCount times are redriven by SQS to the dead letter queue.
+// It returns the url of both queues.
+func EnsureQueue(ctx context.Context, c *sqs.SQS, name string, deadLetterName string, maxReceiveCount int) (string, string, error) {
+	deadLetterOutput, err := c.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{QueueName: aws.String(deadLetterName)})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create queue %s: %w", deadLetterName, err)
+	}
+
+	deadLetterArn, err := queueArn(ctx, c, *deadLetterOutput.QueueUrl)
+	if err != nil {
+		return "", "", err
+	}
+
+	redrivePolicy := fmt.Sprintf(`{"deadLetterTargetArn":"%s","maxReceiveCount":"%d"}`, deadLetterArn, maxReceiveCount)
+	output, err := c.CreateQueueWithContext(ctx, &sqs.CreateQueueInput{
+		QueueName:  aws.String(name),
+		Attributes: map[string]*string{sqs.QueueAttributeNameRedrivePolicy: aws.String(redrivePolicy)},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create queue %s: %w", name, err)
+	}
+
+	return *output.QueueUrl, *deadLetterOutput.QueueUrl, nil
+}
+
+// SubscribeQueue subscribes the queue to the topic with raw message delivery, so the
+// event name travels as a message attribute, and allows the topic to send to the queue.
+func SubscribeQueue(ctx context.Context, snsClient *sns.SNS, sqsClient *sqs.SQS, topicArn string, queueUrl string) error {
+	arn, err := queueArn(ctx, sqsClient, queueUrl)
+	if err != nil {
+		return err
+	}
+
+	policy := fmt.Sprintf(`{
+        "Version": "2012-10-17",
+        "Statement": [
+            {
+                "Effect": "Allow",
+                "Principal": {"Service": "sns.amazonaws.com"},
+                "Action": "sqs:SendMessage",
+                "Resource": "%s",
+                "Condition": {"ArnEquals": {"aws:SourceArn": "%s"}}
+            }
+        ]
+    }`, arn, topicArn)
+	_, err = sqsClient.SetQueueAttributesWithContext(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(queueUrl),
+		Attributes: map[string]*string{sqs.QueueAttributeNamePolicy: aws.String(policy)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set queue policy: %w", err)
+	}
+
+	_, err = snsClient.SubscribeWithContext(ctx, &sns.SubscribeInput{
+		TopicArn:   aws.String(topicArn),
+		Protocol:   aws.String("sqs"),
+		Endpoint:   aws.String(arn),
+		Attributes: map[string]*string{"RawMessageDelivery": aws.String("true")},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe queue to topic: %w", err)
+	}
+
+	return nil
+}
+
+// RedriveDeadLetters moves up to max messages from the dead letter queue back to the source queue.
+func RedriveDeadLetters(ctx context.Context, c *sqs.SQS, deadLetterUrl string, sourceUrl string, max int) (int, error) {
+	moved := 0
+
+	for moved < max {
+		output, err := c.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(deadLetterUrl),
+			MaxNumberOfMessages:   aws.Int64(int64(min(10, max-moved))),
+			MessageAttributeNames: []*string{aws.String(sqs.QueueAttributeNameAll)},
+		})
+		if err != nil {
+			return moved, fmt.Errorf("failed to receive dead letters: %w", err)
+		}
+		if len(output.Messages) == 0 {
+			return moved, nil
+		}
+
+		for _, message := range output.Messages {
+			_, err = c.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+				QueueUrl:          aws.String(sourceUrl),
+				MessageBody:       message.Body,
+				MessageAttributes: message.MessageAttributes,
+			})
+			if err != nil {
+				return moved, fmt.Errorf("failed to redrive dead letter: %w", err)
+			}
+
+			_, err = c.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(deadLetterUrl),
+				ReceiptHandle: message.ReceiptHandle,
+			})
+			if err != nil {
+				return moved, fmt.Errorf("failed to delete redriven dead letter: %w", err)
+			}
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+func queueArn(ctx context.Context, c *sqs.SQS, queueUrl string) (string, error) {
+	output, err := c.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueUrl),
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameQueueArn)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get queue arn: %w", err)
+	}
+
+	return *output.Attributes[sqs.QueueAttributeNameQueueArn], nil
+}
+
+func receiveCount(message *sqs.Message) int {
+	count, err := strconv.Atoi(aws.StringValue(message.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryMailer records the messages it is asked to send instead of sending them.
+type InMemoryMailer struct {
+	messages []Message
+	lock     sync.Mutex
+}
+
+func NewInMemoryMailer() *InMemoryMailer {
+	return &InMemoryMailer{}
+}
+
+func (m *InMemoryMailer) Send(ctx context.Context, message Message) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+// Sent returns the messages sent so far, oldest first.
+func (m *InMemoryMailer) Sent() []Message {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,18 @@
+package mail
+
+import "context"
+
+// Message is an email ready to be sent, with a text body, an HTML body or both.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages, implementations may block on the network so callers sending from an HTTP
+// request should dispatch a SendEmailCommand asynchronously instead.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
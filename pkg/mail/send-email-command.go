@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+)
+
+// SendEmailCommand sends the message rendered from Template, usually dispatched asynchronously so the
+// dispatcher does not wait for the mail server and failures are retried. Durable transports store Data as
+// is, emails carrying secrets, such as tokens, are dispatched synchronously from a queued command that
+// creates the secret instead.
+type SendEmailCommand struct {
+	To       string `validate:"required,email"`
+	Template string `validate:"required"`
+	// Locale picks the variant of Template, the default one when empty.
+	Locale string
+	Data   map[string]string
+}
+
+func (c SendEmailCommand) Id() string {
+	return "send-email-command"
+}
+
+type SendEmailCommandHandler struct {
+	m    Mailer
+	t    *Templates
+	from string
+}
+
+func NewSendEmailCommandHandler(m Mailer, t *Templates, from string) *SendEmailCommandHandler {
+	return &SendEmailCommandHandler{m: m, t: t, from: from}
+}
+
+func (sech SendEmailCommandHandler) Handle(ctx context.Context, c *SendEmailCommand) error {
+	message, err := sech.t.Render(c.Template, c.Locale, c.Data)
+	if err != nil {
+		// Rendering again would fail the same way.
+		return command.NewNonRetryable(err)
+	}
+
+	message.From = sech.from
+	message.To = []string{c.To}
+
+	if err := sech.m.Send(ctx, *message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package mail_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mik3lon/starter-template/pkg/bus/command"
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, message mail.Message) error {
+	return errors.New("connection refused")
+}
+
+func TestSendEmailCommandHandler_Handle(t *testing.T) {
+	mailer := mail.NewInMemoryMailer()
+	handler := mail.NewSendEmailCommandHandler(mailer, newTemplates(t), "no-reply@example.com")
+
+	err := handler.Handle(context.Background(), &mail.SendEmailCommand{
+		To:       "johndoe@example.com",
+		Template: "welcome",
+		Locale:   "es",
+		Data:     map[string]string{"name": "John"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, mailer.Sent(), 1)
+	assert.Equal(t, mail.Message{
+		From:    "no-reply@example.com",
+		To:      []string{"johndoe@example.com"},
+		Subject: "¡Hola, John!",
+		Text:    "Hola John",
+	}, mailer.Sent()[0])
+}
+
+func TestSendEmailCommandHandler_Handle_UnknownTemplateIsNotRetried(t *testing.T) {
+	mailer := mail.NewInMemoryMailer()
+	handler := mail.NewSendEmailCommandHandler(mailer, newTemplates(t), "no-reply@example.com")
+
+	err := handler.Handle(context.Background(), &mail.SendEmailCommand{To: "johndoe@example.com", Template: "unknown"})
+
+	assert.False(t, command.DefaultRetryPolicy().IsRetryable(err))
+	assert.Empty(t, mailer.Sent())
+}
+
+func TestSendEmailCommandHandler_Handle_SendingFailuresAreRetried(t *testing.T) {
+	handler := mail.NewSendEmailCommandHandler(failingMailer{}, newTemplates(t), "no-reply@example.com")
+
+	err := handler.Handle(context.Background(), &mail.SendEmailCommand{To: "johndoe@example.com", Template: "welcome"})
+
+	assert.ErrorContains(t, err, "connection refused")
+	assert.True(t, command.DefaultRetryPolicy().IsRetryable(err))
+}
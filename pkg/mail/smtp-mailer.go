@@ -0,0 +1,192 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends messages to an SMTP server, upgrading the connection with STARTTLS when the server
+// offers it. Without a username it does not authenticate, like local servers such as MailHog expect.
+type SMTPMailer struct {
+	host     string
+	addr     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(host string, port int, username string, password string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		username: username,
+		password: password,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	if len(message.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+
+	body, err := encode(message)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// net/smtp ignores contexts, the deadline bounds the whole conversation instead.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(address(message.From)); err != nil {
+		return err
+	}
+	for _, to := range message.To {
+		if err := client.Rcpt(address(to)); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// address returns the bare address of a `Name <address>` header value.
+func address(value string) string {
+	if start, end := strings.LastIndex(value, "<"), strings.LastIndex(value, ">"); start >= 0 && end > start {
+		return value[start+1 : end]
+	}
+
+	return value
+}
+
+// encode builds the MIME message, a multipart/alternative one when it has both bodies.
+func encode(message Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	messageId, err := newMessageId(address(message.From))
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"From: " + message.From,
+		"To: " + strings.Join(message.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + messageId,
+		"MIME-Version: 1.0",
+	}
+
+	switch {
+	case message.Text != "" && message.HTML != "":
+		w := multipart.NewWriter(&buf)
+		headers = append(headers, fmt.Sprintf("Content-Type: multipart/alternative; boundary=%q", w.Boundary()))
+
+		// Clients pick the last alternative they can display, so the HTML body goes last.
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", message.Text},
+			{"text/html", message.HTML},
+		} {
+			pw, err := w.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType + "; charset=utf-8"},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := writeQuotedPrintable(pw, part.body); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		contentType, body := "text/plain", message.Text
+		if message.HTML != "" {
+			contentType, body = "text/html", message.HTML
+		}
+		headers = append(headers, "Content-Type: "+contentType+"; charset=utf-8", "Content-Transfer-Encoding: quoted-printable")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return append([]byte(strings.Join(headers, "\r\n")+"\r\n\r\n"), buf.Bytes()...), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+func newMessageId(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">", nil
+}
@@ -0,0 +1,169 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	net_mail "net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStub is an SMTP server accepting a single message, like MailHog but in-process.
+type smtpStub struct {
+	listener   net.Listener
+	from       string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	stub := &smtpStub{listener: listener, done: make(chan struct{})}
+	go stub.serve()
+
+	return stub
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 stub ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-stub")
+			reply("250 8BITMIME")
+		case "MAIL":
+			s.from = pathOf(line)
+			reply("250 OK")
+		case "RCPT":
+			s.recipients = append(s.recipients, pathOf(line))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// pathOf returns the address between the angle brackets of a MAIL or RCPT command, ignoring its parameters.
+func pathOf(line string) string {
+	return line[strings.Index(line, "<")+1 : strings.Index(line, ">")]
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	stub := newSMTPStub(t)
+	mailer := mail.NewSMTPMailer("127.0.0.1", stub.port(), "", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := mailer.Send(ctx, mail.Message{
+		From:    "Starter Template <no-reply@example.com>",
+		To:      []string{"johndoe@example.com"},
+		Subject: "¡Hola, John!",
+		Text:    "Hola John",
+		HTML:    "<p>Hola John</p>",
+	})
+	require.NoError(t, err)
+	<-stub.done
+
+	assert.Equal(t, "no-reply@example.com", stub.from)
+	assert.Equal(t, []string{"johndoe@example.com"}, stub.recipients)
+
+	message, err := net_mail.ReadMessage(strings.NewReader(stub.data))
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "¡Hola, John!", subject)
+	assert.Equal(t, "johndoe@example.com", message.Header.Get("To"))
+	assert.NotEmpty(t, message.Header.Get("Message-ID"))
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	var bodies []string
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := parts.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8: Hola John",
+		"text/html; charset=utf-8: <p>Hola John</p>",
+	}, bodies)
+}
+
+func TestSMTPMailer_Send_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	err = mail.NewSMTPMailer("127.0.0.1", port, "", "").Send(context.Background(), mail.Message{
+		From: "no-reply@example.com",
+		To:   []string{"johndoe@example.com"},
+		Text: "Hi",
+	})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	html_template "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	text_template "text/template"
+)
+
+// SubjectBlock is the block of the text templates rendering the subject of the message.
+const SubjectBlock = "subject"
+
+// Templates renders messages from `<name>.<locale>.txt` text templates, which define the subject in a
+// SubjectBlock block, and optional `<name>.<locale>.html` HTML templates next to them. A locale without
+// its own variant falls back to its language, e.g. "es-AR" to "es", then to the default locale.
+type Templates struct {
+	text          map[string]*text_template.Template
+	html          map[string]*html_template.Template
+	defaultLocale string
+}
+
+// NewTemplates parses every template found at the root of the file systems, so malformed templates
+// fail on start up rather than when a message is sent. Later file systems override earlier ones.
+func NewTemplates(defaultLocale string, fsyss ...fs.FS) (*Templates, error) {
+	t := &Templates{
+		text:          make(map[string]*text_template.Template),
+		html:          make(map[string]*html_template.Template),
+		defaultLocale: normalizeLocale(defaultLocale),
+	}
+
+	for _, fsys := range fsyss {
+		if err := t.Add(fsys); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// Add parses every template found at the root of fsys, overriding the known ones with the same name and
+// locale. It is meant to be called on start up, before rendering, as it is not safe for concurrent use.
+func (t *Templates) Add(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+		switch path.Ext(entry.Name()) {
+		case ".txt":
+			parsed, err := text_template.ParseFS(fsys, entry.Name())
+			if err != nil {
+				return err
+			}
+			if parsed.Lookup(SubjectBlock) == nil {
+				return fmt.Errorf("template %s does not define a %s block", entry.Name(), SubjectBlock)
+			}
+			t.text[key] = parsed
+		case ".html":
+			parsed, err := html_template.ParseFS(fsys, entry.Name())
+			if err != nil {
+				return err
+			}
+			t.html[key] = parsed
+		}
+	}
+
+	return nil
+}
+
+// Render renders the subject and bodies of the template name in locale, the message has no sender
+// nor recipients yet.
+func (t *Templates) Render(name string, locale string, data any) (*Message, error) {
+	key, ok := t.resolve(name, locale)
+	if !ok {
+		return nil, NewTemplateNotFound(name, locale)
+	}
+
+	var subject, text bytes.Buffer
+	if err := t.text[key].ExecuteTemplate(&subject, SubjectBlock, data); err != nil {
+		return nil, err
+	}
+	if err := t.text[key].Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	message := &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+	}
+
+	if html, ok := t.html[key]; ok {
+		var buf bytes.Buffer
+		if err := html.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		message.HTML = strings.TrimSpace(buf.String())
+	}
+
+	return message, nil
+}
+
+// resolve returns the key of the variant of name closest to locale.
+func (t *Templates) resolve(name string, locale string) (string, bool) {
+	name, locale = strings.ToLower(name), normalizeLocale(locale)
+	candidates := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, language)
+	}
+	candidates = append(candidates, t.defaultLocale)
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if _, ok := t.text[name+"."+candidate]; ok {
+			return name + "." + candidate, true
+		}
+	}
+
+	return "", false
+}
+
+// normalizeLocale turns "es_AR" and "ES-ar" into "es-ar".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+type TemplateNotFound struct {
+	message string
+}
+
+func NewTemplateNotFound(name string, locale string) TemplateNotFound {
+	return TemplateNotFound{message: fmt.Sprintf("mail template %s not found for locale %q", name, locale)}
+}
+
+func (t TemplateNotFound) Error() string {
+	return t.message
+}
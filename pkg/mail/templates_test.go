@@ -0,0 +1,104 @@
+package mail_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/mik3lon/starter-template/pkg/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTemplates(t *testing.T) *mail.Templates {
+	templates, err := mail.NewTemplates("en", fstest.MapFS{
+		"welcome.en.txt":     {Data: []byte(`{{define "subject"}}Welcome, {{.name}}!{{end}}Hi {{.name}}`)},
+		"welcome.en.html":    {Data: []byte(`<p>Hi {{.name}}</p>`)},
+		"welcome.es.txt":     {Data: []byte(`{{define "subject"}}¡Hola, {{.name}}!{{end}}Hola {{.name}}`)},
+		"welcome.es-mx.txt":  {Data: []byte(`{{define "subject"}}¡Qué onda, {{.name}}!{{end}}Qué onda {{.name}}`)},
+		"reminder.en.txt":    {Data: []byte(`{{define "subject"}}Reminder{{end}}Do not forget`)},
+		"ignored/readme.txt": {Data: []byte(`not a template`)},
+	})
+	require.NoError(t, err)
+
+	return templates
+}
+
+func TestTemplates_Render(t *testing.T) {
+	message, err := newTemplates(t).Render("welcome", "en", map[string]string{"name": "John"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome, John!", message.Subject)
+	assert.Equal(t, "Hi John", message.Text)
+	assert.Equal(t, "<p>Hi John</p>", message.HTML)
+}
+
+func TestTemplates_Render_EscapesHTML(t *testing.T) {
+	message, err := newTemplates(t).Render("welcome", "en", map[string]string{"name": "<script>"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Hi <script>", message.Text)
+	assert.Equal(t, "<p>Hi &lt;script&gt;</p>", message.HTML)
+}
+
+func TestTemplates_Render_FallsBackToLanguageThenDefaultLocale(t *testing.T) {
+	templates := newTemplates(t)
+
+	tests := []struct {
+		locale  string
+		subject string
+	}{
+		{"es_MX", "¡Qué onda, John!"},
+		{"es-AR", "¡Hola, John!"},
+		{"ES", "¡Hola, John!"},
+		{"fr-FR", "Welcome, John!"},
+		{"", "Welcome, John!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			message, err := templates.Render("welcome", tt.locale, map[string]string{"name": "John"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, message.Subject)
+		})
+	}
+}
+
+func TestTemplates_Render_TextOnly(t *testing.T) {
+	message, err := newTemplates(t).Render("reminder", "es", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Do not forget", message.Text)
+	assert.Empty(t, message.HTML)
+}
+
+func TestTemplates_Render_UnknownTemplate(t *testing.T) {
+	_, err := newTemplates(t).Render("unknown", "en", nil)
+
+	assert.IsType(t, mail.TemplateNotFound{}, err)
+}
+
+func TestNewTemplates_RequiresSubject(t *testing.T) {
+	_, err := mail.NewTemplates("en", fstest.MapFS{
+		"welcome.en.txt": {Data: []byte(`Hi {{.name}}`)},
+	})
+
+	assert.Error(t, err)
+}
+
+func TestTemplates_Add_OverridesKnownTemplates(t *testing.T) {
+	templates := newTemplates(t)
+
+	err := templates.Add(fstest.MapFS{
+		"welcome.en.txt": {Data: []byte(`{{define "subject"}}Hello, {{.name}}!{{end}}Hello {{.name}}`)},
+		"invoice.en.txt": {Data: []byte(`{{define "subject"}}Your invoice{{end}}Attached`)},
+	})
+	require.NoError(t, err)
+
+	welcome, err := templates.Render("welcome", "en", map[string]string{"name": "John"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, John!", welcome.Subject)
+
+	invoice, err := templates.Render("invoice", "en", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Your invoice", invoice.Subject)
+}
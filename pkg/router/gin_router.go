@@ -4,6 +4,7 @@ import (
 	"encoding/gob"
 	"github.com/gin-gonic/gin"
 	user_domain "github.com/mik3lon/starter-template/internal/app/module/user/domain"
+	"github.com/mik3lon/starter-template/pkg/bus/command"
 	"net/http"
 )
 
@@ -26,6 +27,9 @@ type GoogleUserInfo struct {
 func NewGinRouter() *GinRouter {
 	gob.Register(user_domain.User{})
 	engine := gin.Default()
+	// Handlers pass the gin context to the buses, its values and cancellation must come from the request.
+	engine.ContextWithFallback = true
+	engine.Use(idempotencyKey)
 
 	return &GinRouter{
 		engine: engine,
@@ -33,10 +37,10 @@ func NewGinRouter() *GinRouter {
 }
 
 func (g *GinRouter) Handle(method, path string, handler gin.HandlerFunc, middleware ...Middleware) {
-	// Wrap the handler with middleware
+	// Wrap the handler with middleware, the first one given running first
 	finalHandler := handler
-	for _, m := range middleware {
-		finalHandler = wrapMiddleware(m, finalHandler)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		finalHandler = wrapMiddleware(middleware[i], finalHandler)
 	}
 
 	// Register the route
@@ -62,6 +66,16 @@ func (g *GinRouter) Handler() http.Handler {
 	return g.engine
 }
 
+// IdempotencyKeyHeader carries the key making the commands of retried requests run once.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+func idempotencyKey(c *gin.Context) {
+	if key := c.GetHeader(IdempotencyKeyHeader); key != "" {
+		c.Request = c.Request.WithContext(command.WithIdempotencyKey(c.Request.Context(), key))
+	}
+	c.Next()
+}
+
 // Helper function to wrap Middleware to gin.HandlerFunc
 func wrapMiddleware(m Middleware, next gin.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,36 @@
+package transaction
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+// GormTransactionManager is a Manager keeping the Gorm transaction in the context, repositories
+// pick it up through DB.
+type GormTransactionManager struct {
+	db *gorm.DB
+}
+
+func NewGormTransactionManager(db *gorm.DB) *GormTransactionManager {
+	return &GormTransactionManager{db: db}
+}
+
+func (m *GormTransactionManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(unitOfWorkContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	// Gorm rolls back and re-panics when fn panics.
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, unitOfWorkContextKey{}, tx))
+	})
+}
+
+// DB returns the transaction of the unit of work running in ctx, or db outside of one.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(unitOfWorkContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,24 @@
+package transaction
+
+import (
+	"context"
+)
+
+// Manager runs units of work, every repository used within one sharing its transaction.
+type Manager interface {
+	// Do runs fn in a transaction committed when fn succeeds and rolled back when it fails or
+	// panics. Called within another unit of work, fn joins its transaction.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type unitOfWorkContextKey struct{}
+
+// Detach returns a copy of ctx no longer carrying the unit of work, for work running once the
+// transaction of ctx has finished.
+func Detach(ctx context.Context) context.Context {
+	if ctx.Value(unitOfWorkContextKey{}) == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, unitOfWorkContextKey{}, nil)
+}